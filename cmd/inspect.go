@@ -0,0 +1,232 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"ikl/pkg/registry"
+	"ikl/pkg/ui"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/spf13/cobra"
+)
+
+var inspectRaw bool
+
+var inspectCmd = &cobra.Command{
+	Use:   "inspect <registry/repo:tag>",
+	Short: "查看镜像 Manifest、各平台镜像配置与层的详细信息",
+	Long: `读取镜像的 Manifest 或 Image Index，展开各平台的 Manifest，显示 digest、mediaType、
+层 (大小与压缩格式)、镜像配置 (环境变量、Entrypoint、Labels、构建历史) 以及注解。
+镜像地址也可以使用 digest (repo@sha256:...)，用于查看 Index 中的单个平台。
+--raw 原样输出仓库返回的 Manifest，--output json/yaml 输出完整结构。`,
+	Example: `  ikl inspect registry.example.com/my-app/worker:v1.2.0 --insecure
+  ikl inspect docker.io/library/nginx:1.27 --proxy http://127.0.0.1:7890
+  ikl inspect docker.io/library/nginx:1.27 --raw | jq .
+  ikl inspect docker.io/library/nginx:1.27 --output json | jq '.manifests[].config.env'`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		validateRegistryArgs()
+		if outputFormat == ui.FormatCSV {
+			handleError(fmt.Errorf("inspect 不支持 csv 输出 (支持: table, json, yaml)"))
+		}
+
+		ref, err := name.ParseReference(normalizeURL(args[0]))
+		if err != nil {
+			handleError(fmt.Errorf("无效的镜像地址 %q: %w", args[0], err))
+		}
+		repo := ref.Context()
+
+		client, err := registry.NewClient(repo.RegistryStr(), username, password, clientTLS(), proxy, noProxy)
+		handleError(err)
+
+		ctx := context.Background()
+		if inspectRaw {
+			var raw []byte
+			_, err := registry.DefaultRetryPolicy.Do(ctx, "获取 "+ref.String()+" 清单", logf, func() (err error) {
+				raw, err = client.RawManifest(ctx, repo.RepositoryStr(), ref.Identifier())
+				return err
+			})
+			handleError(err)
+			_, err = os.Stdout.Write(raw)
+			handleError(err)
+			return
+		}
+
+		logf("🔍 正在获取 %s 的清单...\n", ref.String())
+
+		var detail *registry.ManifestDetail
+		_, err = registry.DefaultRetryPolicy.Do(ctx, "获取 "+ref.String()+" 详情", logf, func() (err error) {
+			detail, err = client.Inspect(ctx, repo.RepositoryStr(), ref.Identifier())
+			return err
+		})
+		handleError(err)
+
+		if ui.IsStructured(outputFormat) {
+			handleError(ui.Render(outputFormat, detail, nil, nil))
+			return
+		}
+
+		fmt.Printf("镜像: %s\n", ref.String())
+		printManifestDetail(detail, "")
+	},
+}
+
+// printManifestDetail 以文本形式输出 Manifest，Index 依次输出各平台的 Manifest
+func printManifestDetail(m *registry.ManifestDetail, title string) {
+	if title != "" {
+		fmt.Printf("\n==> %s\n", title)
+	}
+	fmt.Printf("Digest:     %s\n", m.Digest)
+	fmt.Printf("MediaType:  %s\n", m.MediaType)
+	fmt.Printf("大小:       %s\n", formatBytes(m.Size))
+	if m.Platform != "" {
+		fmt.Printf("平台:       %s\n", m.Platform)
+	}
+	if m.ArtifactType != "" {
+		fmt.Printf("制品类型:   %s\n", m.ArtifactType)
+	}
+	if m.Subject != "" {
+		fmt.Printf("引用:       %s\n", m.Subject)
+	}
+	if m.Error != "" {
+		printf("⚠️  %s\n", m.Error)
+	}
+	printKeyValues("", "注解", m.Annotations)
+
+	if m.IsIndex() {
+		fmt.Printf("\n平台 Manifest (%d):\n", len(m.Manifests))
+		var data [][]string
+		for i, child := range m.Manifests {
+			data = append(data, []string{fmt.Sprintf("%d", i+1), manifestLabel(child), child.Digest, child.MediaType, formatBytes(child.Size)})
+		}
+		ui.RenderTable([]string{"序号", "平台 (PLATFORM)", "DIGEST", "MEDIATYPE", "大小 (SIZE)"}, data)
+		for i, child := range m.Manifests {
+			printManifestDetail(child, fmt.Sprintf("[%d] %s", i+1, manifestLabel(child)))
+		}
+		return
+	}
+
+	if c := m.Config; c != nil {
+		fmt.Printf("\n配置: %s (%s, %s)\n", c.Digest, c.MediaType, formatBytes(c.Size))
+		if !c.Created.IsZero() {
+			printField("创建时间", c.Created.Local().Format("2006-01-02 15:04:05"))
+		}
+		printField("作者", c.Author)
+		printField("用户", c.User)
+		printField("工作目录", c.WorkingDir)
+		printField("Entrypoint", formatCommand(c.Entrypoint))
+		printField("Cmd", formatCommand(c.Cmd))
+		printField("端口", strings.Join(c.ExposedPorts, ", "))
+		printField("卷", strings.Join(c.Volumes, ", "))
+		printField("停止信号", c.StopSignal)
+		if len(c.Env) > 0 {
+			fmt.Println("  环境变量:")
+			for _, env := range c.Env {
+				printf("    %s\n", env)
+			}
+		}
+		printKeyValues("  ", "Labels", c.Labels)
+	}
+
+	if len(m.Layers) > 0 {
+		var total int64
+		var data [][]string
+		for i, l := range m.Layers {
+			total += l.Size
+			compression := l.Compression
+			if compression == "" {
+				compression = "-"
+			}
+			data = append(data, []string{fmt.Sprintf("%d", i+1), l.Digest, formatBytes(l.Size), compression, l.MediaType})
+		}
+		fmt.Printf("\n层 (%d, 共 %s):\n", len(m.Layers), formatBytes(total))
+		ui.RenderTable([]string{"序号", "DIGEST", "大小 (SIZE)", "压缩 (COMPRESSION)", "MEDIATYPE"}, data)
+		for i, l := range m.Layers {
+			if len(l.Annotations) > 0 {
+				printKeyValues("", fmt.Sprintf("层 %d 注解", i+1), l.Annotations)
+			}
+		}
+	}
+
+	if m.Config != nil && len(m.Config.History) > 0 {
+		var data [][]string
+		for i, h := range m.Config.History {
+			created := "-"
+			if !h.Created.IsZero() {
+				created = h.Created.Local().Format("2006-01-02 15:04")
+			}
+			createdBy := strings.Join(strings.Fields(h.CreatedBy), " ")
+			if len(createdBy) > 100 {
+				createdBy = createdBy[:97] + "..."
+			}
+			if h.EmptyLayer {
+				createdBy += " (空层)"
+			}
+			data = append(data, []string{fmt.Sprintf("%d", i+1), created, createdBy})
+		}
+		fmt.Printf("\n构建历史 (%d):\n", len(m.Config.History))
+		ui.RenderTable([]string{"序号", "创建时间 (CREATED)", "命令 (CREATED BY)"}, data)
+	}
+}
+
+// manifestLabel 返回 Index 中子 Manifest 的显示名称，attestation 等没有平台的条目显示其类型
+func manifestLabel(m *registry.ManifestDetail) string {
+	switch {
+	case m.Platform != "":
+		return m.Platform
+	case m.Annotations["vnd.docker.reference.type"] != "":
+		return m.Annotations["vnd.docker.reference.type"]
+	case m.ArtifactType != "":
+		return m.ArtifactType
+	default:
+		return "unknown"
+	}
+}
+
+func printField(label, value string) {
+	if value != "" {
+		fmt.Printf("  %s: %s\n", label, value)
+	}
+}
+
+// printKeyValues 按键排序输出注解或 Labels，indent 为标题的缩进
+func printKeyValues(indent, title string, kv map[string]string) {
+	if len(kv) == 0 {
+		return
+	}
+	keys := make([]string, 0, len(kv))
+	for k := range kv {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	fmt.Printf("%s%s:\n", indent, title)
+	for _, k := range keys {
+		printf("%s  %s=%s\n", indent, k, kv[k])
+	}
+}
+
+// formatCommand 将 Entrypoint/Cmd 格式化为 JSON 数组形式
+func formatCommand(args []string) string {
+	if len(args) == 0 {
+		return ""
+	}
+	quoted := make([]string, len(args))
+	for i, a := range args {
+		quoted[i] = fmt.Sprintf("%q", a)
+	}
+	return "[" + strings.Join(quoted, ", ") + "]"
+}
+
+func init() {
+	rootCmd.AddCommand(inspectCmd)
+
+	inspectCmd.Flags().StringVarP(&username, "username", "u", "", "用户名")
+	inspectCmd.Flags().StringVarP(&password, "password", "p", "", "密码")
+	inspectCmd.Flags().BoolVar(&insecure, "insecure", false, "允许 HTTP 或跳过 TLS 验证")
+	inspectCmd.Flags().BoolVar(&inspectRaw, "raw", false, "原样输出仓库返回的 Manifest")
+	inspectCmd.Flags().StringVarP(&outputFormat, "output", "o", ui.FormatTable, "输出格式: table, json, yaml")
+	addTLSFlags(inspectCmd)
+}
@@ -3,53 +3,73 @@ package cmd
 import (
 	"context"
 	"fmt"
+	"ikl/pkg/config"
 	"ikl/pkg/registry"
+	"ikl/pkg/tagfilter"
+	"ikl/pkg/tlsutil"
 	"ikl/pkg/ui"
 	"os"
 	"sort"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/spf13/cobra"
 )
 
 var (
-	registryURL string
-	username    string
-	password    string
-	repoName    string
-	insecure    bool
+	registryURL  string
+	username     string
+	password     string
+	repoName     string
+	insecure     bool
+	tagSelector  tagfilter.Selector
+	outputFormat string
+	tlsOpts      tlsutil.Options
 )
 
 var listImagesCmd = &cobra.Command{
-	Use:     "list-images",
-	Short:   "列出仓库中的所有镜像名称",
-	Example: `  ikl list-images --registry registry.example.com --user admin --pass 123456 --proxy http://127.0.0.1:7890`,
+	Use:   "list-images",
+	Short: "列出仓库中的所有镜像名称",
+	Example: `  ikl list-images --registry registry.example.com --user admin --pass 123456 --proxy http://127.0.0.1:7890
+  ikl list-images --registry registry.example.com --output json | jq -r '.[]'`,
 	Run: func(cmd *cobra.Command, args []string) {
 		validateRegistryArgs()
 
-		client, err := registry.NewClient(registryURL, username, password, insecure, proxy, noProxy)
+		client, err := registry.NewClient(registryURL, username, password, clientTLS(), proxy, noProxy)
 		handleError(err)
 
-		fmt.Printf("🔍 正在连接仓库 %s 获取目录...\n", registryURL)
+		logf("🔍 正在连接仓库 %s 获取目录...\n", registryURL)
 
 		repos, err := client.ListRepositories(context.Background())
 		if err != nil {
 			// 针对 Harbor 等仓库禁用 Catalog API 的情况进行友好提示
 			if strings.Contains(err.Error(), "UNAUTHORIZED") || strings.Contains(err.Error(), "unauthorized") {
-				fmt.Println("❌ 权限验证失败，或服务端拒绝了 Catalog 请求。")
-				fmt.Println("💡 提示：")
-				fmt.Println("   1. 请检查账号密码是否正确。")
-				fmt.Println("   2. 如果这是 Harbor 仓库，Harbor 默认禁用了 Docker 原生 Catalog API (/v2/_catalog)。")
-				fmt.Println("      这会导致无法使用 list-images 列出所有镜像，但 list-tags 和 migrate 功能不受影响。")
-				fmt.Println("      (请在 config.yaml 中直接指定具体的镜像名称进行迁移)")
+				logf("❌ 权限验证失败，或服务端拒绝了 Catalog 请求。\n")
+				logf("💡 提示：\n")
+				logf("   1. 请检查账号密码是否正确。\n")
+				logf("   2. 如果这是 Harbor 仓库，Harbor 默认禁用了 Docker 原生 Catalog API (/v2/_catalog)。\n")
+				logf("      这会导致无法使用 list-images 列出所有镜像，但 list-tags 和 migrate 功能不受影响。\n")
+				logf("      (请在 config.yaml 中直接指定具体的镜像名称进行迁移)\n")
 				os.Exit(1)
 			}
 			handleError(err)
 		}
 
+		if ui.IsStructured(outputFormat) {
+			if repos == nil {
+				repos = []string{}
+			}
+			rows := make([][]string, 0, len(repos))
+			for _, repo := range repos {
+				rows = append(rows, []string{repo})
+			}
+			handleError(ui.Render(outputFormat, repos, []string{"repository"}, rows))
+			return
+		}
+
 		if len(repos) == 0 {
-			fmt.Println("⚠️  仓库为空或无权查看目录。")
+			logf("⚠️  仓库为空或无权查看目录。\n")
 			return
 		}
 
@@ -64,66 +84,60 @@ var listImagesCmd = &cobra.Command{
 }
 
 var listTagsCmd = &cobra.Command{
-	Use:     "list-tags",
-	Short:   "列出指定镜像的所有标签详情",
-	Example: `  ikl list-tags --registry registry.example.com --repo my-app/worker --insecure --proxy http://127.0.0.1:7890`,
+	Use:   "list-tags",
+	Short: "列出指定镜像的所有标签详情",
+	Example: `  ikl list-tags --registry registry.example.com --repo my-app/worker --insecure --proxy http://127.0.0.1:7890
+  ikl list-tags --registry docker.io --repo rook/ceph --semver ">=1.18 <2.0" --latest 5
+  ikl list-tags --registry docker.io --repo rook/ceph --output csv > tags.csv`,
 	Run: func(cmd *cobra.Command, args []string) {
 		validateRegistryArgs()
 		if repoName == "" {
 			handleError(fmt.Errorf("必须通过 --repo 指定镜像名称"))
 		}
 
-		client, err := registry.NewClient(registryURL, username, password, insecure, proxy, noProxy)
+		client, err := registry.NewClient(registryURL, username, password, clientTLS(), proxy, noProxy)
 		handleError(err)
 
-		fmt.Printf("🔍 正在获取 %s/%s 的标签列表...\n", registryURL, repoName)
+		logf("🔍 正在获取 %s/%s 的标签列表...\n", registryURL, repoName)
 
 		tags, err := client.ListTags(context.Background(), repoName)
 		handleError(err)
 
 		if len(tags) == 0 {
-			fmt.Println("⚠️  该镜像没有标签。")
+			logf("⚠️  该镜像没有标签。\n")
+			renderTagDetails(nil)
 			return
 		}
 
 		sort.Strings(tags)
 
-		fmt.Printf("📋 共找到 %d 个标签，正在获取详细信息 (并发数: 10)...\n", len(tags))
-
-		type result struct {
-			index int
-			info  *registry.TagDetail
-			err   error
+		// newest 筛选已获取的标签详情，展示时直接复用
+		cached := make(map[string]*registry.TagDetail)
+		if !tagSelector.IsZero() {
+			filter, err := tagfilter.New(tagSelector)
+			handleError(err)
+			total := len(tags)
+			tags, err = filter.Apply(tags, tagCreatedLookup(context.Background(), client, repoName, cached))
+			handleError(err)
+			logf("🔎 Tag 筛选: %d -> %d\n", total, len(tags))
+			if len(tags) == 0 {
+				logf("⚠️  没有符合筛选条件的标签。\n")
+				renderTagDetails(nil)
+				return
+			}
 		}
 
-		resultsCh := make(chan result, len(tags))
-		sem := make(chan struct{}, 10)
-		var wg sync.WaitGroup
+		logf("📋 共找到 %d 个标签，正在获取详细信息 (并发数: %d)...\n", len(tags), tagDetailConcurrency)
 
-		for i, tag := range tags {
-			wg.Add(1)
-			go func(idx int, t string) {
-				defer wg.Done()
-				sem <- struct{}{}
-				defer func() { <-sem }()
-
-				info, err := client.GetTagDetail(context.Background(), repoName, t)
-				resultsCh <- result{index: idx, info: info, err: err}
-			}(i, tag)
-		}
-
-		go func() {
-			wg.Wait()
-			close(resultsCh)
-		}()
-
-		detailsMap := make(map[string]*registry.TagDetail)
-		for res := range resultsCh {
-			if res.err == nil {
-				detailsMap[tags[res.index]] = res.info
-			} else {
-				detailsMap[tags[res.index]] = &registry.TagDetail{Name: tags[res.index]}
+		detailsMap := cachedTagDetails(context.Background(), client, repoName, tags, cached)
+
+		if ui.IsStructured(outputFormat) {
+			details := make([]*registry.TagDetail, 0, len(tags))
+			for _, tag := range tags {
+				details = append(details, detailsMap[tag])
 			}
+			renderTagDetails(details)
+			return
 		}
 
 		var data [][]string
@@ -171,6 +185,131 @@ var listTagsCmd = &cobra.Command{
 	},
 }
 
+// renderTagDetails 以 json/yaml/csv 输出标签详情，table 格式下不输出任何内容
+func renderTagDetails(details []*registry.TagDetail) {
+	if !ui.IsStructured(outputFormat) {
+		return
+	}
+	if details == nil {
+		details = []*registry.TagDetail{}
+	}
+
+	rows := make([][]string, 0, len(details))
+	for _, d := range details {
+		created := ""
+		if !d.Created.IsZero() {
+			created = d.Created.UTC().Format(time.RFC3339)
+		}
+		rows = append(rows, []string{
+			d.Name,
+			d.Digest,
+			strings.Join(d.Architectures, ";"),
+			fmt.Sprintf("%d", d.Size),
+			created,
+			fmt.Sprintf("%t", d.IsIndex),
+		})
+	}
+	handleError(ui.Render(outputFormat, details, []string{"name", "digest", "architectures", "size", "created", "isIndex"}, rows))
+}
+
+// tagDetailConcurrency 并发获取标签详情时的最大请求数
+const tagDetailConcurrency = 10
+
+// fetchTagDetails 并发获取多个标签的详细信息，获取失败的标签仅保留名称，失败原因按标签返回
+func fetchTagDetails(ctx context.Context, client *registry.Client, repo string, tags []string) (map[string]*registry.TagDetail, map[string]error) {
+	type result struct {
+		index int
+		info  *registry.TagDetail
+		err   error
+	}
+
+	resultsCh := make(chan result, len(tags))
+	sem := make(chan struct{}, tagDetailConcurrency)
+	var wg sync.WaitGroup
+
+	for i, tag := range tags {
+		wg.Add(1)
+		go func(idx int, t string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			var info *registry.TagDetail
+			_, err := registry.DefaultRetryPolicy.Do(ctx, "获取 "+repo+":"+t+" 详情", logf, func() (err error) {
+				info, err = client.GetTagDetail(ctx, repo, t)
+				return err
+			})
+			resultsCh <- result{index: idx, info: info, err: err}
+		}(i, tag)
+	}
+
+	go func() {
+		wg.Wait()
+		close(resultsCh)
+	}()
+
+	detailsMap := make(map[string]*registry.TagDetail)
+	errs := make(map[string]error)
+	for res := range resultsCh {
+		if res.err == nil {
+			detailsMap[tags[res.index]] = res.info
+		} else {
+			detailsMap[tags[res.index]] = &registry.TagDetail{Name: tags[res.index]}
+			errs[tags[res.index]] = res.err
+		}
+	}
+	return detailsMap, errs
+}
+
+// cachedTagDetails 获取标签详情，cached 中已有的标签不再重复请求
+func cachedTagDetails(ctx context.Context, client *registry.Client, repo string, tags []string, cached map[string]*registry.TagDetail) map[string]*registry.TagDetail {
+	var missing []string
+	for _, tag := range tags {
+		if cached[tag] == nil {
+			missing = append(missing, tag)
+		}
+	}
+	detailsMap, _ := fetchTagDetails(ctx, client, repo, missing)
+	for _, tag := range tags {
+		if info := cached[tag]; info != nil {
+			detailsMap[tag] = info
+		}
+	}
+	return detailsMap
+}
+
+// tagCreatedLookup 为 newest 筛选提供按标签查询镜像创建时间的能力
+// 任一标签无法获取创建时间时返回错误并列出这些标签，以免按零值时间排序选错标签
+// cached 不为空时记录获取成功的标签详情，供后续展示复用
+func tagCreatedLookup(ctx context.Context, client *registry.Client, repo string, cached map[string]*registry.TagDetail) tagfilter.CreatedLookup {
+	return func(tags []string) (map[string]time.Time, error) {
+		details, errs := fetchTagDetails(ctx, client, repo, tags)
+		if cached != nil {
+			for tag, info := range details {
+				if errs[tag] == nil {
+					cached[tag] = info
+				}
+			}
+		}
+		created := make(map[string]time.Time, len(tags))
+		var missing []string
+		for _, tag := range tags {
+			switch {
+			case errs[tag] != nil:
+				missing = append(missing, fmt.Sprintf("%s (%v)", tag, errs[tag]))
+			case details[tag].Created.IsZero():
+				missing = append(missing, fmt.Sprintf("%s (未能从镜像配置中读取创建时间)", tag))
+			default:
+				created[tag] = details[tag].Created
+			}
+		}
+		if len(missing) > 0 {
+			return nil, fmt.Errorf("以下标签无法获取创建时间: %s", strings.Join(missing, ", "))
+		}
+		return created, nil
+	}
+}
+
 func formatBytes(b int64) string {
 	const unit = 1024
 	if b < unit {
@@ -192,6 +331,8 @@ func init() {
 	listImagesCmd.Flags().StringVarP(&username, "username", "u", "", "用户名")
 	listImagesCmd.Flags().StringVarP(&password, "password", "p", "", "密码")
 	listImagesCmd.Flags().BoolVar(&insecure, "insecure", false, "允许 HTTP 或跳过 TLS 验证")
+	listImagesCmd.Flags().StringVarP(&outputFormat, "output", "o", ui.FormatTable, "输出格式: table, json, yaml, csv")
+	addTLSFlags(listImagesCmd)
 	listImagesCmd.MarkFlagRequired("registry")
 
 	listTagsCmd.Flags().StringVar(&registryURL, "registry", "", "仓库地址")
@@ -199,11 +340,36 @@ func init() {
 	listTagsCmd.Flags().StringVarP(&username, "username", "u", "", "用户名")
 	listTagsCmd.Flags().StringVarP(&password, "password", "p", "", "密码")
 	listTagsCmd.Flags().BoolVar(&insecure, "insecure", false, "允许 HTTP 或跳过 TLS 验证")
+	listTagsCmd.Flags().StringVar(&tagSelector.Include, "include", "", "仅显示匹配该正则的标签")
+	listTagsCmd.Flags().StringVar(&tagSelector.Exclude, "exclude", "", "排除匹配该正则的标签")
+	listTagsCmd.Flags().StringVar(&tagSelector.Semver, "semver", "", "语义化版本约束 (如 \">=1.18 <2.0\")")
+	listTagsCmd.Flags().BoolVar(&tagSelector.Prerelease, "prerelease", false, "预发布版本参与 --semver/--latest 筛选")
+	listTagsCmd.Flags().IntVar(&tagSelector.Latest, "latest", 0, "按语义化版本仅保留最新的 N 个标签")
+	listTagsCmd.Flags().IntVar(&tagSelector.Newest, "newest", 0, "按创建时间仅保留最新的 N 个标签")
+	listTagsCmd.Flags().StringVarP(&outputFormat, "output", "o", ui.FormatTable, "输出格式: table, json, yaml, csv")
+	addTLSFlags(listTagsCmd)
 	listTagsCmd.MarkFlagRequired("registry")
 	listTagsCmd.MarkFlagRequired("repo")
 }
 
+// addTLSFlags 为直接连接仓库的命令添加 CA 证书与双向 TLS 参数
+func addTLSFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&tlsOpts.CAFile, "ca-file", "", "额外信任的 CA 证书 (PEM)，用于私有 CA 签发的仓库证书")
+	cmd.Flags().StringVar(&tlsOpts.CertFile, "cert-file", "", "双向 TLS 客户端证书 (PEM)")
+	cmd.Flags().StringVar(&tlsOpts.KeyFile, "key-file", "", "双向 TLS 客户端私钥 (PEM)")
+	cmd.Flags().StringVar(&tlsOpts.ServerName, "server-name", "", "覆盖校验证书时使用的服务器名称")
+}
+
+// clientTLS 返回命令行参数指定的 TLS 设置
+func clientTLS() tlsutil.Options {
+	opts := tlsOpts
+	opts.Insecure = insecure
+	return opts
+}
+
 func validateRegistryArgs() {
+	handleError(ui.ValidateFormat(outputFormat))
+	config.RegisterSecret(password)
 	registryURL = strings.TrimPrefix(registryURL, "http://")
 	registryURL = strings.TrimPrefix(registryURL, "https://")
 	registryURL = strings.TrimSuffix(registryURL, "/")
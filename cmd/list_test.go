@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"context"
+	"ikl/pkg/registry"
+	"ikl/pkg/tagfilter"
+	"ikl/pkg/tlsutil"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	ggcrregistry "github.com/google/go-containerregistry/pkg/registry"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+)
+
+// TestListTagsNewestReusesDetails newest 筛选已获取的标签详情在展示时不再重复请求
+func TestListTagsNewestReusesDetails(t *testing.T) {
+	t.Setenv("DOCKER_CONFIG", t.TempDir())
+	t.Setenv("REGISTRY_AUTH_FILE", t.TempDir()+"/auth.json")
+
+	var manifestGets atomic.Int64
+	handler := ggcrregistry.New(ggcrregistry.Logger(log.New(io.Discard, "", 0)))
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet && strings.Contains(r.URL.Path, "/manifests/") {
+			manifestGets.Add(1)
+		}
+		handler.ServeHTTP(w, r)
+	}))
+	defer srv.Close()
+
+	client, err := registry.NewClient(strings.TrimPrefix(srv.URL, "http://"), "", "", tlsutil.Options{Insecure: true}, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	tags := []string{"v1", "v2", "v3"}
+	for i, tag := range tags {
+		img, err := random.Image(256, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if img, err = mutate.CreatedAt(img, v1.Time{Time: base.Add(time.Duration(i) * time.Hour)}); err != nil {
+			t.Fatal(err)
+		}
+		ref, err := name.ParseReference(client.URL+"/library/app:"+tag, name.Insecure)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := remote.Write(ref, img); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ctx := context.Background()
+	filter, err := tagfilter.New(tagfilter.Selector{Newest: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cached := make(map[string]*registry.TagDetail)
+	selected, err := filter.Apply(tags, tagCreatedLookup(ctx, client, "library/app", cached))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(selected, []string{"v3", "v2"}) {
+		t.Fatalf("selected = %v, want [v3 v2]", selected)
+	}
+
+	manifestGets.Store(0)
+	details := cachedTagDetails(ctx, client, "library/app", selected, cached)
+	if n := manifestGets.Load(); n != 0 {
+		t.Errorf("manifest GETs after newest lookup = %d, want 0", n)
+	}
+	for i, tag := range selected {
+		want := base.Add(time.Duration(2-i) * time.Hour)
+		if details[tag] == nil || !details[tag].Created.Equal(want) {
+			t.Errorf("%s detail = %+v, want created %s", tag, details[tag], want)
+		}
+	}
+
+	// 未缓存的标签仍然会获取
+	details = cachedTagDetails(ctx, client, "library/app", []string{"v1"}, nil)
+	if n := manifestGets.Load(); n == 0 {
+		t.Error("uncached tag was not fetched")
+	}
+	if details["v1"] == nil || !details["v1"].Created.Equal(base) {
+		t.Errorf("v1 detail = %+v, want created %s", details["v1"], base)
+	}
+}
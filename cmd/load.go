@@ -0,0 +1,137 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"ikl/pkg/bundle"
+	"ikl/pkg/config"
+	"ikl/pkg/journal"
+	"ikl/pkg/registry"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	loadFrom        string
+	loadPrefix      string
+	loadConcurrency int
+	loadForce       bool
+)
+
+var loadCmd = &cobra.Command{
+	Use:   "load",
+	Short: "将离线包 (OCI layout / docker save) 中的镜像推送到目标仓库",
+	Long: `读取 ikl save 生成的 OCI image layout 目录或压缩包，或 docker save 生成的压缩包，
+将其中所有镜像 (包括多架构 Manifest List) 推送到配置文件 destination_registries 中的目标仓库。
+会复用 migrate 的目标仓库配置、Harbor 项目/ACR 命名空间自动创建以及进度条展示。`,
+	Example: `  ikl load --from bundle.tar.gz --config config.yaml
+  ikl load --from bundle/ --config config.yaml --prefix team-a
+  ikl load --from images.tar --config config.yaml --prefix library=mirror`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if loadFrom == "" {
+			handleError(fmt.Errorf("必须通过 --from 指定离线包路径"))
+		}
+
+		cfg, err := config.LoadConfig(configPath)
+		handleError(err)
+
+		concurrency := cfg.Concurrency
+		if cmd.Flags().Changed("concurrency") || concurrency == 0 {
+			concurrency = loadConcurrency
+		}
+		if concurrency < 1 {
+			handleError(fmt.Errorf("并发数必须大于 0，当前为 %d", concurrency))
+		}
+
+		retryPolicy, err := newRetryPolicy(cfg.Retry)
+		handleError(err)
+
+		fmt.Printf("📥 正在读取离线包 %s ...\n", loadFrom)
+		b, err := bundle.Open(loadFrom)
+		handleError(err)
+		defer b.Close()
+
+		fmt.Printf("📦 离线包格式: %s，共 %d 个镜像\n", b.Format, len(b.Images))
+		for _, s := range b.Skipped {
+			fmt.Printf("⚠️  跳过 %s\n", s)
+		}
+
+		destinations, err := destinationConfigs(cfg)
+		handleError(err)
+		printDestinations(destinations)
+		initDestinations(destinations)
+
+		var jobs []migrateJob
+		for _, img := range b.Images {
+			var src *registry.Source
+			if img.Index != nil {
+				src, err = registry.NewIndexSource(img.Index)
+			} else {
+				src, err = registry.NewImageSource(img.Image)
+			}
+			origin := fmt.Sprintf("%s:%s:%s", loadFrom, img.Repo, img.Tag)
+			if err != nil {
+				printf("❌ 读取镜像失败 [%s]: %v\n", origin, err)
+				for _, dst := range destinations {
+					dst.stats.failed++
+				}
+				continue
+			}
+
+			jobs = append(jobs, migrateJob{
+				dstName: rewriteRepoPrefix(img.Repo, loadPrefix),
+				tag:     img.Tag,
+				source:  src,
+				origin:  origin,
+			})
+		}
+
+		checkRepositoryConflicts(jobs, destinations)
+
+		fmt.Printf("📦 共 %d 个导入任务 x %d 个目标仓库，并发数: %d\n", len(jobs), len(destinations), concurrency)
+
+		// 导入不写状态日志，避免覆盖 migrate 的断点续传记录
+		m := &migrator{
+			journal:      journal.New(""),
+			destinations: destinations,
+			retry:        retryPolicy,
+			force:        loadForce,
+		}
+		m.runAll(context.Background(), jobs, concurrency)
+		printDestinationStats(destinations)
+	},
+}
+
+// rewriteRepoPrefix 按 --prefix 改写镜像名称
+// "team-a" 表示在镜像名前追加项目，如 library/app -> team-a/library/app
+// "library=team-a" 表示替换开头的路径，如 library/app -> team-a/app
+func rewriteRepoPrefix(repo, rule string) string {
+	if rule == "" {
+		return repo
+	}
+	if old, replacement, ok := strings.Cut(rule, "="); ok {
+		old = strings.Trim(old, "/")
+		replacement = strings.Trim(replacement, "/")
+		if repo == old {
+			return replacement
+		}
+		if rest, found := strings.CutPrefix(repo, old+"/"); found {
+			if replacement == "" {
+				return rest
+			}
+			return replacement + "/" + rest
+		}
+		return repo
+	}
+	return strings.Trim(rule, "/") + "/" + repo
+}
+
+func init() {
+	rootCmd.AddCommand(loadCmd)
+	loadCmd.Flags().StringVarP(&configPath, "config", "c", "config.yaml", "配置文件路径，使用其中的 destination_registries")
+	loadCmd.Flags().StringVar(&loadFrom, "from", "", "离线包路径：OCI layout 目录、.tar/.tar.gz 压缩包或 docker save 文件")
+	loadCmd.Flags().StringVar(&loadPrefix, "prefix", "", "改写镜像名称前缀：'team-a' 追加项目，'library=team-a' 替换开头路径")
+	loadCmd.Flags().IntVar(&loadConcurrency, "concurrency", 1, "并发导入任务数 (优先于配置文件中的 concurrency)")
+	loadCmd.Flags().BoolVar(&loadForce, "force", false, "即使目标 Tag 已指向相同 digest 也强制推送")
+}
@@ -0,0 +1,39 @@
+package cmd
+
+import "testing"
+
+func TestRewriteRepoPrefix(t *testing.T) {
+	tests := []struct {
+		repo string
+		rule string
+		want string
+	}{
+		// 未指定 --prefix
+		{"library/app", "", "library/app"},
+		{"app", "", "app"},
+
+		// 追加项目
+		{"library/app", "team-a", "team-a/library/app"},
+		{"app", "team-a/", "team-a/app"},
+		{"library/app", "/mirror/team-a/", "mirror/team-a/library/app"},
+
+		// 替换开头的路径
+		{"library/app", "library=team-a", "team-a/app"},
+		{"library/app", "library/=/team-a/", "team-a/app"},
+		{"library/nested/app", "library=team-a", "team-a/nested/app"},
+		{"library/nested/app", "library/nested=team-a", "team-a/app"},
+		{"library/nested/app", "library=mirror/team-a", "mirror/team-a/nested/app"},
+		{"library", "library=team-a", "team-a"},
+		{"library/app", "library=", "app"},
+
+		// 开头的路径不匹配时保持不变
+		{"other/app", "library=team-a", "other/app"},
+		{"librarything/app", "library=team-a", "librarything/app"},
+		{"team/library/app", "library=team-a", "team/library/app"},
+	}
+	for _, tt := range tests {
+		if got := rewriteRepoPrefix(tt.repo, tt.rule); got != tt.want {
+			t.Errorf("rewriteRepoPrefix(%q, %q) = %q, want %q", tt.repo, tt.rule, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"ikl/pkg/config"
+	"ikl/pkg/credentials"
+	"ikl/pkg/registry"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+var (
+	loginUsername      string
+	loginPassword      string
+	loginPasswordStdin bool
+)
+
+var loginCmd = &cobra.Command{
+	Use:   "login <registry>",
+	Short: "登录镜像仓库并保存凭据",
+	Long: `校验账号密码后将凭据保存到与 docker login 相同的位置：
+设置了 $REGISTRY_AUTH_FILE 时写入该文件 (podman)，否则写入 ~/.docker/config.json；
+config.json 中配置了 credsStore/credHelpers 时交由对应的 docker-credential-* 凭据助手保存。
+之后 migrate、list-images 等命令在未配置账号密码时会自动使用这些凭据。`,
+	Example: `  ikl login registry.example.com -u admin
+  echo "$HARBOR_PASSWORD" | ikl login ykl.io:40443 -u admin --password-stdin --insecure`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		server := normalizeURL(args[0])
+
+		if loginPassword != "" && loginPasswordStdin {
+			handleError(fmt.Errorf("--password 与 --password-stdin 不能同时使用"))
+		}
+		// 标准输入只能用于读取密码，与 docker login 一致要求通过 -u 指定用户名
+		if loginPasswordStdin && loginUsername == "" {
+			handleError(fmt.Errorf("使用 --password-stdin 时必须通过 -u 指定用户名"))
+		}
+		if loginPassword != "" {
+			logf("⚠️  通过命令行传递密码并不安全，建议使用 --password-stdin\n")
+		}
+
+		if loginUsername == "" {
+			fmt.Print("Username: ")
+			line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+			if err != nil && err != io.EOF {
+				handleError(err)
+			}
+			loginUsername = strings.TrimSpace(line)
+		}
+		if loginUsername == "" {
+			handleError(fmt.Errorf("用户名不能为空"))
+		}
+
+		switch {
+		case loginPasswordStdin:
+			data, err := io.ReadAll(os.Stdin)
+			handleError(err)
+			loginPassword = strings.TrimRight(string(data), "\r\n")
+		case loginPassword == "":
+			fmt.Print("Password: ")
+			data, err := term.ReadPassword(int(os.Stdin.Fd()))
+			fmt.Println()
+			handleError(err)
+			loginPassword = string(data)
+		}
+		if loginPassword == "" {
+			handleError(fmt.Errorf("密码不能为空"))
+		}
+		config.RegisterSecret(loginPassword)
+
+		client, err := registry.NewClient(server, loginUsername, loginPassword, clientTLS(), proxy, noProxy)
+		handleError(err)
+		logf("🔐 正在登录 %s ...\n", server)
+		handleError(client.Ping(context.Background()))
+
+		location, err := credentials.Store(server, loginUsername, loginPassword)
+		handleError(err)
+		fmt.Printf("✅ 登录成功，凭据已保存到 %s\n", location)
+	},
+}
+
+var logoutCmd = &cobra.Command{
+	Use:     "logout <registry>",
+	Short:   "删除 ikl login 保存的仓库凭据",
+	Example: `  ikl logout registry.example.com`,
+	Args:    cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		server := normalizeURL(args[0])
+		location, err := credentials.Erase(server)
+		handleError(err)
+		fmt.Printf("👋 已从 %s 删除 %s 的凭据\n", location, server)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(loginCmd)
+	rootCmd.AddCommand(logoutCmd)
+	loginCmd.Flags().StringVarP(&loginUsername, "username", "u", "", "用户名，未指定时交互输入")
+	loginCmd.Flags().StringVarP(&loginPassword, "password", "p", "", "密码，未指定时交互输入")
+	loginCmd.Flags().BoolVar(&loginPasswordStdin, "password-stdin", false, "从标准输入读取密码 (需同时指定 -u)")
+	loginCmd.Flags().BoolVar(&insecure, "insecure", false, "允许 HTTP 或跳过 TLS 证书校验")
+	addTLSFlags(loginCmd)
+}
@@ -4,23 +4,42 @@ import (
 	"context"
 	"fmt"
 	"ikl/pkg/config"
-	"ikl/pkg/harbor"
+	"ikl/pkg/credentials"
+	"ikl/pkg/journal"
+	"ikl/pkg/provider"
 	"ikl/pkg/registry"
+	"ikl/pkg/report"
+	"ikl/pkg/signature"
+	"ikl/pkg/tagfilter"
+	"ikl/pkg/ui"
+	"sort"
 	"strings"
 	"sync"
+	"time"
 
 	v1 "github.com/google/go-containerregistry/pkg/v1"
-	"github.com/schollz/progressbar/v3"
 	"github.com/spf13/cobra"
 )
 
-var configPath string
+var (
+	configPath         string
+	migrateConcurrency int
+	migrateResume      bool
+	migrateForce       bool
+	migrateDryRun      bool
+	migrateReport      string
+	migrateReportFmt   string
+)
 
 var migrateCmd = &cobra.Command{
-	Use:     "migrate",
-	Short:   "根据配置文件批量迁移镜像",
-	Long:    `读取 YAML 配置文件，将镜像从源仓库复制到目标仓库。会自动识别 Manifest List 从而支持多架构迁移。`,
-	Example: `  ikl migrate --config config.yaml --proxy http://127.0.0.1:7890`,
+	Use:   "migrate",
+	Short: "根据配置文件批量迁移镜像",
+	Long:  `读取 YAML 配置文件，将镜像从源仓库复制到一个或多个目标仓库。会自动识别 Manifest List 从而支持多架构迁移。`,
+	Example: `  ikl migrate --config config.yaml --proxy http://127.0.0.1:7890
+  ikl migrate --config config.yaml --resume
+  ikl migrate --config config.yaml --dry-run
+  ikl migrate --config config.yaml --report report.json
+  ikl migrate --config config.yaml --report junit.xml`,
 	Run: func(cmd *cobra.Command, args []string) {
 		if configPath == "" {
 			handleError(fmt.Errorf("请提供配置文件路径"))
@@ -35,177 +54,802 @@ var migrateCmd = &cobra.Command{
 
 		fmt.Println("🚀 开始执行镜像迁移任务...")
 		printSourceRegistries(cfg, images)
-		dstRegistry, dstCfg, err := destinationConfig(cfg)
+		destinations, err := destinationConfigs(cfg)
 		handleError(err)
-		fmt.Printf("目标仓库: %s (Type: %s, Insecure: %v)\n", dstRegistry, dstCfg.Type, dstCfg.Insecure)
+		printDestinations(destinations)
 
-		if proxy != "" {
-			fmt.Printf("🌐 全局代理: %s\n", proxy)
-			if noProxy != "" {
-				fmt.Printf("🛑 排除代理 (NoProxy): %s\n", noProxy)
-			}
+		concurrency := cfg.Concurrency
+		if cmd.Flags().Changed("concurrency") || concurrency == 0 {
+			concurrency = migrateConcurrency
 		}
-		fmt.Println("------------------------------------------------")
-
-		// 初始化 Harbor 客户端 (如果需要)
-		var harborClient *harbor.Client
-		// 用于缓存已检查过的项目，避免重复调用 API
-		checkedProjects := make(map[string]bool)
-		var mu sync.Mutex
-
-		if strings.ToLower(dstCfg.Type) == "harbor" {
-			hClient, err := harbor.NewClient(
-				dstRegistry,
-				dstCfg.Username,
-				dstCfg.Password,
-				dstCfg.Insecure,
-				proxy,
-				noProxy,
-			)
-			if err != nil {
-				handleError(fmt.Errorf("初始化 Harbor 客户端失败: %v", err))
+		if concurrency < 1 {
+			handleError(fmt.Errorf("并发数必须大于 0，当前为 %d", concurrency))
+		}
+
+		retryPolicy, err := newRetryPolicy(cfg.Retry)
+		handleError(err)
+
+		// 2. 初始化目标仓库客户端与 Provider
+		initDestinations(destinations)
+
+		// 状态日志：--resume 时沿用已有记录，否则重新开始
+		statePath := journal.DefaultPath(configPath)
+		stateJournal, err := openJournal(statePath, migrateResume)
+		handleError(err)
+
+		reportFormat := migrateReportFmt
+		if migrateReport != "" && reportFormat == "" {
+			reportFormat = report.FormatFromPath(migrateReport)
+		}
+		if reportFormat != "" && reportFormat != report.FormatJSON && reportFormat != report.FormatJUnit {
+			handleError(fmt.Errorf("不支持的报告格式 %q (支持: json, junit)", reportFormat))
+		}
+
+		m := &migrator{
+			journal:      stateJournal,
+			destinations: destinations,
+			retry:        retryPolicy,
+			artifacts:    cfg.Artifacts,
+			force:        migrateForce,
+		}
+		if migrateReport != "" {
+			m.report = report.New()
+		}
+
+		ctx := context.Background()
+
+		// 3. 遍历镜像列表，展开为 镜像:Tag 粒度的迁移任务
+		jobs, failures := resolveJobs(ctx, cfg, images, m.printf)
+		checkRepositoryConflicts(jobs, destinations)
+		for _, dst := range destinations {
+			dst.stats.failed += len(failures)
+			for _, f := range failures {
+				m.report.Add(report.Entry{
+					Registry: dst.registry,
+					Source:   f.source,
+					Status:   report.StatusFailed,
+					Error:    config.Redact(f.err.Error()),
+				})
 			}
-			harborClient = hClient
-			fmt.Println("⚓️ 已启用 Harbor 自动项目管理")
 		}
 
-		// 2. 初始化 Registry 客户端
-		srcClients := make(map[string]*registry.Client)
+		if migrateDryRun {
+			printMigratePlan(ctx, jobs, destinations, concurrency, cfg.Artifacts.Attestations)
+			return
+		}
+
+		fmt.Printf("📦 共 %d 个迁移任务 x %d 个目标仓库，并发数: %d\n", len(jobs), len(destinations), concurrency)
+
+		// 4. 通过 Worker Pool 并发执行迁移，每个源镜像只解析一次并依次推送到所有目标仓库
+		m.runAll(ctx, jobs, concurrency)
 
-		dstClient, err := registry.NewClient(
-			normalizeURL(dstRegistry),
-			dstCfg.Username,
-			dstCfg.Password,
-			dstCfg.Insecure,
-			proxy,
+		total := printDestinationStats(destinations)
+		if total.failed > 0 {
+			fmt.Printf("💡 可使用 --resume 仅重试失败或未完成的条目 (状态日志: %s)\n", statePath)
+		}
+		if m.report != nil {
+			handleError(m.report.Write(migrateReport, reportFormat))
+			fmt.Printf("📄 迁移报告已写入 %s (%s)\n", migrateReport, reportFormat)
+		}
+	},
+}
+
+// openJournal 打开状态日志：resume 时沿用已有记录，否则重新开始
+// 重新开始时若已有上次运行的记录，提示其将被覆盖，避免上次失败的条目被悄悄丢弃
+func openJournal(path string, resume bool) (*journal.Journal, error) {
+	if resume {
+		j, err := journal.Load(path)
+		if err != nil {
+			return nil, err
+		}
+		fmt.Printf("📝 断点续传模式，状态日志: %s\n", path)
+		return j, nil
+	}
+
+	if previous, err := journal.Load(path); err == nil {
+		if total, unfinished := previous.Counts(); total > 0 {
+			fmt.Printf("📝 未指定 --resume，将重置状态日志 %s (上次运行记录了 %d 个条目，其中 %d 个失败或未完成)\n", path, total, unfinished)
+		}
+	}
+	return journal.New(path), nil
+}
+
+// printDestinations 输出目标仓库列表与代理设置
+func printDestinations(destinations []*migrateDestination) {
+	fmt.Println("目标仓库列表:")
+	for _, dst := range destinations {
+		signLabel := ""
+		if dst.cfg.Sign != nil {
+			signLabel = ", 推送后签名"
+		}
+		fmt.Printf("  - %s (Type: %s, Insecure: %v%s%s)\n", dst.registry, dst.cfg.Type, dst.cfg.Insecure, proxyLabel(dst.cfg), signLabel)
+	}
+
+	if proxy != "" {
+		fmt.Printf("🌐 全局代理: %s\n", proxy)
+		if noProxy != "" {
+			fmt.Printf("🛑 排除代理 (NoProxy): %s\n", noProxy)
+		}
+	}
+	fmt.Println("------------------------------------------------")
+}
+
+// initDestinations 初始化目标仓库客户端，并根据 type 选择对应的 Provider 处理项目/命名空间管理
+func initDestinations(destinations []*migrateDestination) {
+	var err error
+	for _, dst := range destinations {
+		// 未配置账号密码时使用 ikl login / docker login 保存的凭据，Harbor/ACR 的 API 同样需要
+		if dst.cfg.Username == "" && dst.cfg.Password == "" {
+			cred, err := credentials.Lookup(dst.registry)
+			handleError(err)
+			dst.cfg.Username, dst.cfg.Password = cred.Username, cred.Password
+		}
+
+		dst.client, err = registry.NewClient(
+			dst.registry,
+			dst.cfg.Username,
+			dst.cfg.Password,
+			dst.cfg.TLS(),
+			registryProxy(dst.cfg),
 			noProxy,
 		)
 		handleError(err)
 
-		ctx := context.Background()
-		successCount := 0
-		failCount := 0
-
-		// 3. 遍历镜像列表
-		for _, img := range images {
-			registryURL := normalizeURL(img.Registry)
-
-			srcClient, ok := srcClients[registryURL]
-			if !ok {
-				srcCfg := sourceConfigForRegistry(cfg, registryURL)
-				client, err := registry.NewClient(
-					registryURL,
-					srcCfg.Username,
-					srcCfg.Password,
-					srcCfg.Insecure,
-					proxy,
-					noProxy,
-				)
-				handleError(err)
-				srcClients[registryURL] = client
-				srcClient = client
-			}
+		dst.provider, err = provider.New(provider.Options{
+			Registry: dst.registry,
+			Config:   dst.cfg,
+			Proxy:    registryProxy(dst.cfg),
+			NoProxy:  noProxy,
+		})
+		if err != nil {
+			handleError(fmt.Errorf("初始化目标仓库 %s 失败: %w", dst.registry, err))
+		}
 
-			dstName := img.TargetName
-			if dstName == "" {
-				dstName = img.Name
+		if dst.cfg.Sign != nil {
+			if dst.signer, err = signature.NewSigner(dst.cfg.Sign.Key, []byte(dst.cfg.Sign.Password)); err != nil {
+				handleError(fmt.Errorf("destination_registries.%s.sign: %w", dst.registry, err))
 			}
+		}
+
+		switch strings.ToLower(dst.cfg.Visibility) {
+		case "", "public", "private":
+		default:
+			handleError(fmt.Errorf("目标仓库 %s 的 visibility 只能为 public 或 private，当前为 %q", dst.registry, dst.cfg.Visibility))
+		}
+	}
+}
+
+// printDestinationStats 按目标仓库输出迁移结果并返回汇总
+func printDestinationStats(destinations []*migrateDestination) migrateStats {
+	fmt.Println("------------------------------------------------")
+	var total migrateStats
+	for _, dst := range destinations {
+		s := dst.stats
+		fmt.Printf("🎯 %s: 成功 %d, 已是最新 %d, 失败 %d, 跳过 %d\n", dst.registry, s.success, s.upToDate, s.failed, s.skipped)
+		total.success += s.success
+		total.upToDate += s.upToDate
+		total.failed += s.failed
+		total.skipped += s.skipped
+	}
+	fmt.Printf("🎉 任务结束。成功: %d, 已是最新: %d, 失败: %d, 跳过: %d\n", total.success, total.upToDate, total.failed, total.skipped)
+	return total
+}
+
+// migrateDestination 描述一个目标仓库及其客户端
+type migrateDestination struct {
+	registry string
+	cfg      config.RegistryConfig
+	client   *registry.Client
+	provider provider.Provider // 由 cfg.Type 选择，负责项目/命名空间管理与推送后回调
+	signer   *signature.Signer // 推送后为镜像签名，未配置 sign 时为空
+	stats    migrateStats
+}
+
+// repoName 返回镜像在该目标仓库中的名称
+func (d *migrateDestination) repoName(name string) string {
+	return d.provider.RepositoryName(name)
+}
 
-			// --- Harbor 项目自动创建逻辑 ---
-			if harborClient != nil {
-				// 提取项目名称 (例如 "rook/ceph" -> "rook")
-				parts := strings.Split(dstName, "/")
-				if len(parts) > 1 {
-					project := parts[0]
-
-					mu.Lock()
-					if !checkedProjects[project] {
-						err := harborClient.EnsureProject(project)
-						if err != nil {
-							fmt.Printf("⚠️  无法自动创建/检查 Harbor 项目 '%s': %v\n", project, err)
-							// 不终止程序，尝试继续推送，也许项目已经存在只是 API 权限问题
-						}
-						checkedProjects[project] = true
-					}
-					mu.Unlock()
+// migrateStats 统计单个目标仓库的迁移结果
+type migrateStats struct {
+	success  int
+	upToDate int
+	failed   int
+	skipped  int
+}
+
+// migrateJob 描述单个 镜像:Tag 的迁移任务
+type migrateJob struct {
+	srcClient *registry.Client
+	img       config.ImageEntry
+	dstName   string
+	tag       string              // 目标 Tag，为空时按 digest 推送
+	digest    string              // 按 digest 固定的源镜像，为空时按 tag 拉取
+	verifier  *signature.Verifier // 推送前校验源镜像签名，未配置 verify 时为空
+
+	// 以下字段用于已在本地读取的源镜像 (如 ikl load)，此时 srcClient 为空
+	source *registry.Source
+	origin string // 源镜像描述，如 bundle.tar.gz:library/app:v1
+}
+
+func (j migrateJob) srcRef() string {
+	if j.origin != "" {
+		return j.origin
+	}
+	return registry.RefString(j.srcClient.URL, j.img.Name, j.srcIdent())
+}
+
+func (j migrateJob) dstRef(dst *migrateDestination) string {
+	return registry.RefString(dst.client.URL, dst.repoName(j.dstName), j.dstIdent())
+}
+
+// dstImage 返回不含仓库地址的目标镜像，如 library/app:v1 或 library/app@sha256:...
+func (j migrateJob) dstImage() string {
+	return strings.TrimPrefix(registry.RefString("", j.dstName, j.dstIdent()), "/")
+}
+
+// srcIdent 返回拉取源镜像使用的 Tag 或 digest
+func (j migrateJob) srcIdent() string {
+	if j.digest != "" {
+		return j.digest
+	}
+	return j.tag
+}
+
+// dstIdent 返回推送到目标仓库使用的 Tag，未指定目标 Tag 时为源镜像的 digest
+func (j migrateJob) dstIdent() string {
+	if j.tag != "" {
+		return j.tag
+	}
+	return j.digest
+}
+
+// resolveSource 拉取并筛选源镜像，配置了 verify 时校验签名，校验失败的镜像不会被推送
+func (j migrateJob) resolveSource(ctx context.Context, keepAttestations bool) (*registry.Source, error) {
+	src, err := registry.ResolveSource(ctx, j.srcClient, j.img.Name, j.srcIdent(), j.img.Architectures, keepAttestations)
+	if err != nil || j.verifier == nil {
+		return src, err
+	}
+	if err := registry.VerifySource(ctx, src, j.srcClient, j.img.Name, j.verifier); err != nil {
+		return nil, err
+	}
+	return src, nil
+}
+
+// resolveFailure 记录获取或筛选 Tag 失败的镜像
+type resolveFailure struct {
+	source string // 源镜像，如 docker.io/rook/ceph
+	err    error
+}
+
+// resolveJobs 将镜像列表展开为 镜像:Tag 粒度的任务，未指定 Tag 时获取源仓库的全部 Tag 并按 tag_filter 筛选
+// 返回任务列表以及获取或筛选 Tag 失败的镜像；statusf 用于输出源仓库限速暂停等日志
+func resolveJobs(ctx context.Context, cfg *config.MigrateConfig, images []config.ImageEntry, statusf func(format string, a ...interface{})) ([]migrateJob, []resolveFailure) {
+	srcClients := make(map[string]*registry.Client)
+	srcVerifiers := make(map[string]*signature.Verifier)
+	var jobs []migrateJob
+	var failed []resolveFailure
+
+	for _, img := range images {
+		registryURL := normalizeURL(img.Registry)
+
+		srcClient, ok := srcClients[registryURL]
+		if !ok {
+			srcCfg := sourceConfigForRegistry(cfg, registryURL)
+			client, err := registry.NewClient(
+				registryURL,
+				srcCfg.Username,
+				srcCfg.Password,
+				srcCfg.TLS(),
+				registryProxy(srcCfg),
+				noProxy,
+			)
+			handleError(err)
+			rateLimit, err := newRateLimit(registryURL, srcCfg.RateLimit)
+			handleError(err)
+			client.SetRateLimit(rateLimit, statusf)
+			srcClients[registryURL] = client
+			srcClient = client
+
+			if srcCfg.Verify != nil {
+				verifier, err := signature.NewVerifier(*srcCfg.Verify)
+				if err != nil {
+					handleError(fmt.Errorf("source_registries.%s.verify: %w", registryURL, err))
 				}
+				srcVerifiers[registryURL] = verifier
+			}
+		}
+
+		verifier := srcVerifiers[registryURL]
+		if img.Verify != nil {
+			var err error
+			if verifier, err = signature.NewVerifier(*img.Verify); err != nil {
+				handleError(fmt.Errorf("镜像 %s 的 verify: %w", img.Name, err))
 			}
-			// --------------------------------
+		}
+		if verifier != nil {
+			fmt.Printf("🔏 镜像 %s 迁移前将校验 cosign 签名\n", img.Name)
+		}
 
-			// 如果配置中未指定 Tags，则自动获取源仓库所有 Tags
-			tagsToMigrate := img.Tags
-			if len(tagsToMigrate) == 0 {
-				fmt.Printf("🔍 未指定 Tag，正在获取 %s 的所有 Tag...\n", img.Name)
-				fetchedTags, err := srcClient.ListTags(ctx, img.Name)
+		dstName := img.TargetName
+		if dstName == "" {
+			dstName = img.Name
+		}
+
+		// 按 digest 固定的镜像不获取 Tag 列表，Tags 为推送到目标仓库的 Tag，未指定时仅按 digest 推送
+		if img.Digest != "" {
+			tags := img.Tags
+			if len(tags) == 0 {
+				tags = []string{""}
+			}
+			for _, tag := range tags {
+				jobs = append(jobs, migrateJob{
+					srcClient: srcClient,
+					img:       img,
+					dstName:   dstName,
+					tag:       tag,
+					digest:    img.Digest,
+					verifier:  verifier,
+				})
+			}
+			continue
+		}
+
+		// 如果配置中未指定 Tags，则自动获取源仓库所有 Tags
+		tagsToMigrate := img.Tags
+		if len(tagsToMigrate) == 0 {
+			fmt.Printf("🔍 未指定 Tag，正在获取 %s 的所有 Tag...\n", img.Name)
+			fetchedTags, err := srcClient.ListTags(ctx, img.Name)
+			if err != nil {
+				printf("❌ 获取 Tag 失败 [%s]: %v\n", img.Name, err)
+				failed = append(failed, resolveFailure{source: srcClient.URL + "/" + img.Name, err: err})
+				continue
+			}
+			tagsToMigrate = fetchedTags
+
+			if img.TagFilter != nil {
+				filter, err := tagfilter.New(*img.TagFilter)
+				if err == nil {
+					tagsToMigrate, err = filter.Apply(fetchedTags, tagCreatedLookup(ctx, srcClient, img.Name, nil))
+				}
 				if err != nil {
-					fmt.Printf("❌ 获取 Tag 失败 [%s]: %v\n", img.Name, err)
-					failCount++
+					printf("❌ Tag 筛选失败 [%s]: %v\n", img.Name, err)
+					failed = append(failed, resolveFailure{source: srcClient.URL + "/" + img.Name, err: err})
 					continue
 				}
-				tagsToMigrate = fetchedTags
+				fmt.Printf("🔎 镜像 %s Tag 筛选: %d -> %d\n", img.Name, len(fetchedTags), len(tagsToMigrate))
 			}
+		}
 
-			if len(img.Architectures) > 0 {
-				fmt.Printf("🎯 镜像 %s (-> %s) 指定架构: %v\n", img.Name, dstName, img.Architectures)
+		if len(img.Architectures) > 0 {
+			fmt.Printf("🎯 镜像 %s (-> %s) 指定架构: %v\n", img.Name, dstName, img.Architectures)
+		}
+
+		for _, tag := range tagsToMigrate {
+			jobs = append(jobs, migrateJob{
+				srcClient: srcClient,
+				img:       img,
+				dstName:   dstName,
+				tag:       tag,
+				verifier:  verifier,
+			})
+		}
+	}
+	return jobs, failed
+}
+
+// checkRepositoryConflicts 检查是否有多个镜像被目标仓库改写到同一个仓库 (如 ACR 只保留名称最后一段)，存在冲突时退出
+func checkRepositoryConflicts(jobs []migrateJob, destinations []*migrateDestination) {
+	names := make([]string, 0, len(jobs))
+	for _, job := range jobs {
+		names = append(names, job.dstName)
+	}
+	for _, dst := range destinations {
+		if err := provider.CheckConflicts(dst.provider, names); err != nil {
+			handleError(fmt.Errorf("目标仓库 %s: %w", dst.registry, err))
+		}
+	}
+}
+
+// migrator 持有一次迁移中各 worker 共享的状态
+type migrator struct {
+	progress     *ui.MultiProgress
+	journal      *journal.Journal
+	destinations []*migrateDestination
+	report       *report.Report // 未指定 --report 时为空
+	retry        registry.RetryPolicy
+	artifacts    config.ArtifactsConfig
+	force        bool // 目标 Tag 已指向相同 digest 时仍然推送
+	mu           sync.Mutex
+}
+
+// printf 在进度条上方输出一行日志，并对配置中的密钥脱敏；进度条启动前直接输出到 stderr
+func (m *migrator) printf(format string, a ...interface{}) {
+	if m.progress == nil {
+		logf(format, a...)
+		return
+	}
+	m.progress.Printf("%s", config.Redact(fmt.Sprintf(format, a...)))
+}
+
+// pushOutcome 记录推送到单个目标仓库的结果，用于统计、状态日志与迁移报告
+type pushOutcome struct {
+	result    *registry.CopyResult
+	err       error
+	platforms []string
+	bytes     int64 // 实际上传的字节数，不含目标仓库已存在的层
+	duration  time.Duration
+	retries   int  // 拉取与推送的重试次数
+	artifacts int  // 复制的签名、证明与 SBOM 数量
+	signed    bool // 是否已在目标仓库签名
+}
+
+// runAll 通过 Worker Pool 并发执行所有任务，每个运行中的推送显示一个进度条
+func (m *migrator) runAll(ctx context.Context, jobs []migrateJob, concurrency int) {
+	m.progress = ui.NewMultiProgress()
+	jobCh := make(chan migrateJob)
+	var wg sync.WaitGroup
+
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobCh {
+				m.run(ctx, job)
+			}
+		}()
+	}
+
+	for _, job := range jobs {
+		jobCh <- job
+	}
+	close(jobCh)
+	wg.Wait()
+	m.progress.Stop()
+}
+
+// run 解析一次源镜像，并依次推送到所有仍需处理的目标仓库
+func (m *migrator) run(ctx context.Context, job migrateJob) {
+	var pending []*migrateDestination
+	for _, dst := range m.destinations {
+		if migrateResume && m.alreadyMigrated(ctx, job, dst) {
+			m.printf("⏭️  跳过 %s (目标已存在记录的 digest)\n", job.dstRef(dst))
+			m.mu.Lock()
+			dst.stats.skipped++
+			m.mu.Unlock()
+			m.report.Add(report.Entry{
+				Registry:    dst.registry,
+				Source:      job.srcRef(),
+				Destination: job.dstRef(dst),
+				Status:      report.StatusSkipped,
+			})
+			continue
+		}
+		pending = append(pending, dst)
+	}
+	if len(pending) == 0 {
+		return
+	}
+
+	src := job.source
+	resolveRetries := 0
+	if src == nil {
+		var err error
+		resolveRetries, err = m.retry.Do(ctx, "拉取 "+job.srcRef(), m.printf, func() error {
+			src, err = job.resolveSource(ctx, m.artifacts.Attestations)
+			return err
+		})
+		if err != nil {
+			for _, dst := range pending {
+				m.record(job, dst, pushOutcome{err: err, retries: resolveRetries})
+			}
+			return
+		}
+	}
+
+	// 多个目标仓库时在本地缓存层数据，避免重复从源仓库拉取；本地读取的源镜像无需缓存
+	if len(pending) > 1 && job.source == nil {
+		cache, err := registry.NewBlobCache()
+		if err != nil {
+			m.printf("⚠️  创建本地层缓存失败，将为每个目标仓库重复拉取: %v\n", err)
+		} else {
+			defer cache.Close()
+			src.UseCache(cache)
+		}
+	}
+
+	for _, dst := range pending {
+		m.prepare(dst, dst.repoName(job.dstName))
+		out := m.push(ctx, src, job, dst)
+		out.retries += resolveRetries
+		if out.err == nil && !out.result.UpToDate {
+			if hookErr := dst.provider.AfterPush(dst.repoName(job.dstName), job.dstIdent(), out.result.Digest); hookErr != nil {
+				m.printf("⚠️  推送后处理失败 [%s]: %v\n", job.dstRef(dst), hookErr)
+			}
+		}
+		if out.err == nil {
+			out.artifacts = m.copyArtifacts(ctx, src, job, dst)
+		}
+		if out.err == nil && dst.signer != nil {
+			out.signed, out.err = m.sign(ctx, job, dst, out.result.Digest)
+		}
+		m.record(job, dst, out)
+	}
+}
+
+// prepare 在推送前确保目标项目/命名空间存在，并按配置设置可见性
+// 失败时不终止，尝试继续推送，也许项目已经存在只是 API 权限问题
+func (m *migrator) prepare(dst *migrateDestination, repo string) {
+	if err := dst.provider.EnsureNamespace(repo); err != nil {
+		m.printf("⚠️  %v [%s]\n", err, dst.registry)
+	}
+	if visibility := strings.ToLower(dst.cfg.Visibility); visibility != "" {
+		if err := dst.provider.SetVisibility(repo, visibility == "public"); err != nil {
+			m.printf("⚠️  设置可见性失败 '%s' [%s]: %v\n", repo, dst.registry, err)
+		}
+	}
+}
+
+// alreadyMigrated 判断状态日志中记录为成功、且目标仓库当前 digest 与记录一致的条目
+func (m *migrator) alreadyMigrated(ctx context.Context, job migrateJob, dst *migrateDestination) bool {
+	entry, ok := m.journal.Get(job.srcRef(), job.dstRef(dst))
+	if !ok || entry.Status != journal.StatusSuccess || entry.DestinationDigest == "" {
+		return false
+	}
+	digest, err := dst.client.GetDigest(ctx, dst.repoName(job.dstName), job.dstIdent())
+	if err != nil {
+		return false
+	}
+	return digest == entry.DestinationDigest
+}
+
+// push 将源镜像推送到单个目标仓库，并在 progress 中渲染该任务的进度条
+func (m *migrator) push(ctx context.Context, src *registry.Source, job migrateJob, dst *migrateDestination) pushOutcome {
+	if err := m.journal.Record(journal.Entry{
+		Source:       job.srcRef(),
+		Destination:  job.dstRef(dst),
+		SourceDigest: src.SourceDigest,
+		Status:       journal.StatusRunning,
+	}); err != nil {
+		m.printf("⚠️  %v\n", err)
+	}
+
+	m.printf("⏳ 正在迁移 %s -> %s ...\n", job.srcRef(), job.dstRef(dst))
+
+	out := pushOutcome{platforms: src.Platforms}
+	start := time.Now()
+
+	bar := m.progress.AddBar("   " + job.dstRef(dst))
+	defer m.progress.RemoveBar(bar)
+
+	// remote.WithProgress 写入完成后会关闭 channel，因此每次尝试使用新的 channel
+	attempt := func() error {
+		updates := make(chan v1.Update)
+		drained := make(chan struct{})
+		go func() {
+			defer close(drained)
+			for update := range updates {
+				if update.Total > 0 {
+					bar.ChangeMax64(update.Total)
+				}
+				bar.Set64(update.Complete)
 			}
+		}()
+
+		var err error
+		out.result, err = registry.PushImage(ctx, src, dst.client, dst.repoName(job.dstName), job.dstIdent(), updates, m.force)
+
+		// 兜底关闭并忽略重复关闭
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+				}
+			}()
+			close(updates)
+		}()
+		<-drained
+		return err
+	}
+	out.retries, out.err = m.retry.Do(ctx, "推送 "+job.dstRef(dst), m.printf, attempt)
+	out.duration = time.Since(start)
+	if out.err == nil {
+		out.bytes = out.result.BytesTransferred
+	}
+
+	return out
+}
+
+// copyArtifacts 按配置复制签名、证明与 SBOM，目标镜像已是最新时也会补齐之后新增的制品
+// 复制失败仅输出警告，不影响镜像本身的迁移结果
+func (m *migrator) copyArtifacts(ctx context.Context, src *registry.Source, job migrateJob, dst *migrateDestination) int {
+	if job.srcClient == nil || (!m.artifacts.Signatures && !m.artifacts.Referrers) {
+		return 0
+	}
+	var copied int
+	_, err := m.retry.Do(ctx, "复制签名/SBOM "+job.dstRef(dst), m.printf, func() (err error) {
+		copied, err = registry.CopyArtifacts(ctx, src, job.srcClient, dst.client, job.img.Name, dst.repoName(job.dstName), m.artifacts.Signatures, m.artifacts.Referrers, m.printf)
+		return err
+	})
+	if err != nil {
+		m.printf("⚠️  复制签名/SBOM 失败 [%s]: %v\n", job.dstRef(dst), err)
+	}
+	if copied > 0 {
+		m.printf("   🔏 已复制 %d 个签名/证明/SBOM [%s]\n", copied, job.dstRef(dst))
+	}
+	return copied
+}
+
+// sign 为推送后的目标 digest 签名，目标镜像已是最新时也会补齐缺失的签名
+// 签名失败视为迁移失败，避免未签名的镜像被准入控制拒绝而不自知
+func (m *migrator) sign(ctx context.Context, job migrateJob, dst *migrateDestination, digest string) (bool, error) {
+	var created bool
+	_, err := m.retry.Do(ctx, "签名 "+job.dstRef(dst), m.printf, func() (err error) {
+		created, err = registry.SignDigest(ctx, dst.client, dst.repoName(job.dstName), digest, dst.signer)
+		return err
+	})
+	if err != nil {
+		return false, err
+	}
+	if created {
+		m.printf("   🔏 已签名 %s@%s\n", dst.repoName(job.dstName), registry.ShortDigest(digest))
+	}
+	return true, nil
+}
+
+// record 输出单个目标仓库的迁移结果，并写入统计与状态日志
+func (m *migrator) record(job migrateJob, dst *migrateDestination, out pushOutcome) {
+	result, err := out.result, out.err
+	entry := journal.Entry{
+		Source:      job.srcRef(),
+		Destination: job.dstRef(dst),
+	}
+	if result != nil {
+		entry.SourceDigest = result.SourceDigest
+		entry.DestinationDigest = result.Digest
+	}
+	reportEntry := report.Entry{
+		Registry:          dst.registry,
+		Source:            entry.Source,
+		Destination:       entry.Destination,
+		SourceDigest:      entry.SourceDigest,
+		DestinationDigest: entry.DestinationDigest,
+		Platforms:         out.platforms,
+		BytesTransferred:  out.bytes,
+		DurationSeconds:   out.duration.Seconds(),
+		Retries:           out.retries,
+		Artifacts:         out.artifacts,
+		Signed:            out.signed,
+	}
 
-			// 4. 执行迁移
-			for _, tag := range tagsToMigrate {
-				fmt.Printf("⏳ 正在迁移 %s:%s -> %s:%s ...\n", img.Name, tag, dstName, tag)
-
-				updates := make(chan v1.Update)
-				errCh := make(chan error, 1)
-
-				bar := progressbar.DefaultBytes(
-					-1,
-					"   传输中",
-				)
-
-				go func() {
-					for update := range updates {
-						if update.Total > 0 {
-							bar.ChangeMax64(update.Total)
-						}
-						bar.Set64(update.Complete)
-					}
-				}()
-
-				go func() {
-					err := registry.CopyImage(ctx, srcClient, dstClient, img.Name, dstName, tag, updates, img.Architectures)
-
-					func() {
-						defer func() {
-							if r := recover(); r != nil {
-							}
-						}()
-						close(updates)
-					}()
-
-					errCh <- err
-				}()
-
-				err = <-errCh
-				_ = bar.Finish()
-				fmt.Println()
+	m.mu.Lock()
+	switch {
+	case err != nil:
+		dst.stats.failed++
+	case result.UpToDate:
+		dst.stats.upToDate++
+	default:
+		dst.stats.success++
+	}
+	m.mu.Unlock()
+
+	switch {
+	case err != nil:
+		entry.Status = journal.StatusFailed
+		entry.Error = config.Redact(err.Error())
+		reportEntry.Status = report.StatusFailed
+		reportEntry.Error = entry.Error
+		m.printf("   ❌ 失败 [%s]: %v\n", job.dstRef(dst), err)
+	case result.UpToDate:
+		entry.Status = journal.StatusSuccess
+		reportEntry.Status = report.StatusUpToDate
+		m.printf("   ✔️  已是最新 [%s] (%s)\n", job.dstRef(dst), result.Digest)
+	default:
+		entry.Status = journal.StatusSuccess
+		reportEntry.Status = report.StatusSuccess
+		m.printf("   ✅ 完成 [%s]\n", job.dstRef(dst))
+	}
+	m.report.Add(reportEntry)
+
+	if recErr := m.journal.Record(entry); recErr != nil {
+		m.printf("⚠️  %v\n", recErr)
+	}
+}
+
+// printMigratePlan 以 dry-run 方式解析所有任务并渲染迁移计划表，不推送任何数据
+func printMigratePlan(ctx context.Context, jobs []migrateJob, destinations []*migrateDestination, concurrency int, keepAttestations bool) {
+	fmt.Printf("📝 Dry-run 模式：正在生成 %d 个条目 x %d 个目标仓库的迁移计划 (并发数: %d)...\n", len(jobs), len(destinations), concurrency)
+
+	type result struct {
+		plans []*registry.CopyPlan // 与 destinations 一一对应
+		errs  []error
+	}
 
+	results := make([]result, len(jobs))
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+
+	for i, job := range jobs {
+		wg.Add(1)
+		go func(idx int, j migrateJob) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			res := result{
+				plans: make([]*registry.CopyPlan, len(destinations)),
+				errs:  make([]error, len(destinations)),
+			}
+			src, err := j.resolveSource(ctx, keepAttestations)
+			for d, dst := range destinations {
 				if err != nil {
-					fmt.Printf("   ❌ 失败: %v\n", err)
-					failCount++
-				} else {
-					fmt.Printf("   ✅ 完成\n")
-					successCount++
+					res.errs[d] = err
+					continue
 				}
+				res.plans[d], res.errs[d] = registry.PlanPush(ctx, src, dst.client, dst.repoName(j.dstName), j.dstIdent(), migrateForce)
+			}
+			results[idx] = res
+		}(i, job)
+	}
+	wg.Wait()
+
+	var data [][]string
+	var totalSize, totalTransfer int64
+	actionCount := make(map[string]int)
+	var failed []string
+
+	for i, job := range jobs {
+		for d, dst := range destinations {
+			row := []string{fmt.Sprintf("%d", len(data)+1), job.srcRef(), job.dstRef(dst)}
+
+			plan, err := results[i].plans[d], results[i].errs[d]
+			if err != nil {
+				data = append(data, append(row, "-", "-", "-", "-", "error"))
+				failed = append(failed, fmt.Sprintf("%s -> %s: %v", job.srcRef(), job.dstRef(dst), err))
+				continue
 			}
+
+			platformStr := "-"
+			if len(plan.Platforms) > 0 {
+				platformStr = strings.Join(plan.Platforms, ", ")
+			}
+
+			data = append(data, append(row,
+				platformStr,
+				registry.ShortDigest(plan.Digest),
+				formatBytes(plan.Size),
+				formatBytes(plan.Transfer),
+				plan.Action,
+			))
+			actionCount[plan.Action]++
+			totalSize += plan.Size
+			totalTransfer += plan.Transfer
 		}
+	}
 
-		fmt.Println("------------------------------------------------")
-		fmt.Printf("🎉 任务结束。成功: %d, 失败: %d\n", successCount, failCount)
-	},
+	ui.RenderTable([]string{"序号", "源镜像 (SOURCE)", "目标镜像 (DESTINATION)", "平台 (PLATFORMS)", "DIGEST", "镜像大小 (SIZE)", "预计传输 (TRANSFER)", "操作 (ACTION)"}, data)
+
+	for _, f := range failed {
+		printf("❌ %s\n", f)
+	}
+	fmt.Printf("\n计划: create %d, update %d, skip %d, 失败 %d；镜像总大小 %s，预计传输 %s (目标仓库已存在的层不计入，未推送任何数据)\n",
+		actionCount[registry.PlanActionCreate],
+		actionCount[registry.PlanActionUpdate],
+		actionCount[registry.PlanActionSkip],
+		len(failed),
+		formatBytes(totalSize),
+		formatBytes(totalTransfer),
+	)
 }
 
 func init() {
 	rootCmd.AddCommand(migrateCmd)
 	migrateCmd.Flags().StringVarP(&configPath, "config", "c", "config.yaml", "迁移配置文件路径")
+	migrateCmd.Flags().IntVar(&migrateConcurrency, "concurrency", 1, "并发迁移任务数 (优先于配置文件中的 concurrency)")
+	migrateCmd.Flags().BoolVar(&migrateForce, "force", false, "即使目标 Tag 已指向相同 digest 也强制复制")
+	migrateCmd.Flags().BoolVar(&migrateDryRun, "dry-run", false, "仅生成迁移计划，不创建项目/命名空间也不推送任何数据")
+	migrateCmd.Flags().BoolVar(&migrateResume, "resume", false, "断点续传：跳过状态日志中已成功且目标 digest 一致的条目")
+	migrateCmd.Flags().StringVar(&migrateReport, "report", "", "将每个 镜像:Tag 的迁移结果写入报告文件 (如 report.json、junit.xml)")
+	migrateCmd.Flags().StringVar(&migrateReportFmt, "report-format", "", "报告格式: json 或 junit，默认根据 --report 扩展名推断 (.xml 为 junit)")
 }
 
 func normalizeURL(u string) string {
@@ -257,21 +901,102 @@ func printSourceRegistries(cfg *config.MigrateConfig, images []config.ImageEntry
 		authLabel := "匿名"
 		if regCfg.Username != "" || regCfg.Password != "" {
 			authLabel = "需要认证"
+		} else if cred, err := credentials.Lookup(registryURL); err == nil && !cred.Empty() {
+			authLabel = "凭据来自 " + cred.Source
+		}
+		limitLabel := ""
+		if rl := regCfg.RateLimit; rl.RPS > 0 || rl.Manifests > 0 {
+			limitLabel = fmt.Sprintf(", 限速: %g rps, %d 次/%s", rl.RPS, rl.Manifests, rl.Window)
 		}
-		fmt.Printf("  - %s (Insecure: %v, %s)\n", registryURL, regCfg.Insecure, authLabel)
+		fmt.Printf("  - %s (Insecure: %v, %s%s%s)\n", registryURL, regCfg.Insecure, authLabel, limitLabel, proxyLabel(regCfg))
 	}
 }
 
-func destinationConfig(cfg *config.MigrateConfig) (string, config.RegistryConfig, error) {
-	if len(cfg.DestinationRegs) == 0 {
-		return "", config.RegistryConfig{}, fmt.Errorf("destination_registries 不能为空")
+// registryProxy 返回仓库使用的代理：优先使用仓库配置中的 proxy，其次为全局 --proxy
+// 两者均为空时由环境变量 HTTP_PROXY/HTTPS_PROXY/ALL_PROXY 决定
+func registryProxy(regCfg config.RegistryConfig) string {
+	if regCfg.Proxy != "" {
+		return regCfg.Proxy
 	}
-	if len(cfg.DestinationRegs) > 1 {
-		return "", config.RegistryConfig{}, fmt.Errorf("destination_registries 仅支持配置一个目标仓库")
+	return proxy
+}
+
+// proxyLabel 返回仓库单独配置的代理说明，未配置时为空
+func proxyLabel(regCfg config.RegistryConfig) string {
+	if regCfg.Proxy == "" {
+		return ""
 	}
+	return ", 代理: " + regCfg.Proxy
+}
+
+// destinationConfigs 返回按地址排序的目标仓库列表
+func destinationConfigs(cfg *config.MigrateConfig) ([]*migrateDestination, error) {
+	if len(cfg.DestinationRegs) == 0 {
+		return nil, fmt.Errorf("destination_registries 不能为空")
+	}
+
+	seen := make(map[string]bool)
+	var destinations []*migrateDestination
 	for registry, regCfg := range cfg.DestinationRegs {
 		registry = normalizeURL(registry)
-		return registry, withRegistryFallback(regCfg, registry), nil
+		if registry == "" {
+			return nil, fmt.Errorf("destination_registries 存在空的仓库地址")
+		}
+		if seen[registry] {
+			return nil, fmt.Errorf("destination_registries 中仓库 %s 重复配置", registry)
+		}
+		seen[registry] = true
+		destinations = append(destinations, &migrateDestination{
+			registry: registry,
+			cfg:      withRegistryFallback(regCfg, registry),
+		})
+	}
+
+	sort.Slice(destinations, func(i, j int) bool {
+		return destinations[i].registry < destinations[j].registry
+	})
+	return destinations, nil
+}
+
+// newRateLimit 将源仓库的 rate_limit 配置转换为限速规则
+func newRateLimit(registryURL string, cfg config.RateLimitConfig) (registry.RateLimit, error) {
+	if cfg.RPS < 0 || cfg.Manifests < 0 || cfg.Window < 0 {
+		return registry.RateLimit{}, fmt.Errorf("源仓库 %s 的 rate_limit 不能为负数", registryURL)
+	}
+	if cfg.Manifests > 0 && cfg.Window == 0 {
+		return registry.RateLimit{}, fmt.Errorf("源仓库 %s 配置了 rate_limit.manifests 时必须同时配置 window (如 6h)", registryURL)
+	}
+	return registry.RateLimit{
+		RPS:       cfg.RPS,
+		Manifests: cfg.Manifests,
+		Window:    cfg.Window,
+	}, nil
+}
+
+// newRetryPolicy 将配置文件中的 retry 转换为重试策略，未配置的字段使用默认值
+func newRetryPolicy(cfg config.RetryConfig) (registry.RetryPolicy, error) {
+	policy := registry.DefaultRetryPolicy
+	if cfg.Attempts != 0 {
+		policy.Attempts = cfg.Attempts
+	}
+	if cfg.Backoff != 0 {
+		policy.Backoff = cfg.Backoff
+	}
+	if cfg.MaxBackoff != 0 {
+		policy.MaxBackoff = cfg.MaxBackoff
+	}
+	if cfg.Jitter != nil {
+		policy.Jitter = *cfg.Jitter
+	}
+
+	if policy.Attempts < 1 {
+		return policy, fmt.Errorf("retry.attempts 必须大于 0，当前为 %d", policy.Attempts)
+	}
+	if policy.Backoff < 0 || policy.MaxBackoff < 0 {
+		return policy, fmt.Errorf("retry.backoff 与 retry.max_backoff 不能为负数")
+	}
+	if policy.Jitter < 0 || policy.Jitter > 1 {
+		return policy, fmt.Errorf("retry.jitter 必须在 0 到 1 之间，当前为 %g", policy.Jitter)
 	}
-	return "", config.RegistryConfig{}, fmt.Errorf("destination_registries 未配置有效目标仓库")
+	return policy, nil
 }
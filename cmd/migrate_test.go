@@ -0,0 +1,236 @@
+package cmd
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"ikl/pkg/config"
+	"ikl/pkg/journal"
+	"ikl/pkg/provider"
+	"ikl/pkg/registry"
+	"ikl/pkg/report"
+	"ikl/pkg/signature"
+	"ikl/pkg/tlsutil"
+	"ikl/pkg/ui"
+	"io"
+	"log"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	ggcrregistry "github.com/google/go-containerregistry/pkg/registry"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+)
+
+// newTestClient 启动进程内的镜像仓库并返回其客户端
+func newTestClient(t *testing.T) *registry.Client {
+	t.Helper()
+	t.Setenv("DOCKER_CONFIG", t.TempDir())
+	t.Setenv("REGISTRY_AUTH_FILE", t.TempDir()+"/auth.json")
+
+	srv := httptest.NewServer(ggcrregistry.New(ggcrregistry.Logger(log.New(io.Discard, "", 0))))
+	t.Cleanup(srv.Close)
+
+	c, err := registry.NewClient(strings.TrimPrefix(srv.URL, "http://"), "", "", tlsutil.Options{Insecure: true}, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+// writePublicKey 生成一对 ECDSA 密钥，将公钥以 PEM 格式写入临时目录，返回私钥与公钥路径
+func writePublicKey(t *testing.T) (*ecdsa.PrivateKey, string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "cosign.pub")
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return key, path
+}
+
+// writeTestImage 向仓库推送一个随机镜像
+func writeTestImage(t *testing.T, c *registry.Client, repo, tag string) v1.Image {
+	t.Helper()
+	ref, err := name.ParseReference(c.URL+"/"+repo+":"+tag, name.Insecure)
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, err := random.Image(256, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := remote.Write(ref, img); err != nil {
+		t.Fatal(err)
+	}
+	return img
+}
+
+// newTestMigrator 返回推送到 dstClient 的 migrator，不重试并记录报告
+func newTestMigrator(t *testing.T, dstClient *registry.Client, j *journal.Journal) (*migrator, *migrateDestination) {
+	t.Helper()
+	p, err := provider.New(provider.Options{Registry: dstClient.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	dst := &migrateDestination{registry: dstClient.URL, client: dstClient, provider: p}
+	m := &migrator{
+		progress:     ui.NewMultiProgress(),
+		journal:      j,
+		destinations: []*migrateDestination{dst},
+		report:       report.New(),
+		retry:        registry.RetryPolicy{Attempts: 1},
+	}
+	t.Cleanup(m.progress.Stop)
+	return m, dst
+}
+
+// TestMigrateVerifyFailure 签名校验失败时不推送任何数据，并在报告中记为失败
+func TestMigrateVerifyFailure(t *testing.T) {
+	srcClient := newTestClient(t)
+	dstClient := newTestClient(t)
+	img := writeTestImage(t, srcClient, "library/app", "v1")
+
+	// 源镜像没有签名
+	_, pubPath := writePublicKey(t)
+	verifier, err := signature.NewVerifier(signature.Policy{Key: pubPath})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m, dst := newTestMigrator(t, dstClient, journal.New(""))
+	m.run(context.Background(), migrateJob{
+		srcClient: srcClient,
+		img:       config.ImageEntry{Name: "library/app"},
+		dstName:   "library/app",
+		tag:       "v1",
+		verifier:  verifier,
+	})
+
+	if len(m.report.Entries) != 1 {
+		t.Fatalf("报告条目数 = %d, want 1", len(m.report.Entries))
+	}
+	entry := m.report.Entries[0]
+	if entry.Status != report.StatusFailed || !strings.HasPrefix(entry.Error, "签名校验失败") {
+		t.Errorf("报告条目 = %s: %s, want %s: 签名校验失败...", entry.Status, entry.Error, report.StatusFailed)
+	}
+	if dst.stats.failed != 1 {
+		t.Errorf("failed = %d, want 1", dst.stats.failed)
+	}
+
+	repos, err := dstClient.ListRepositories(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(repos) != 0 {
+		t.Errorf("校验失败后目标仓库中存在镜像: %v", repos)
+	}
+	layers, err := img.Layers()
+	if err != nil {
+		t.Fatal(err)
+	}
+	layerDigest, err := layers[0].Digest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exists, err := dstClient.BlobExists(context.Background(), "library/app", layerDigest); err != nil || exists {
+		t.Errorf("校验失败后目标仓库中存在镜像层: exists = %v, err = %v", exists, err)
+	}
+}
+
+// TestMigrateResume --resume 仅跳过记录为成功且目标 digest 与记录一致的条目
+func TestMigrateResume(t *testing.T) {
+	old := migrateResume
+	migrateResume = true
+	defer func() { migrateResume = old }()
+
+	ctx := context.Background()
+	srcClient := newTestClient(t)
+	dstClient := newTestClient(t)
+	writeTestImage(t, srcClient, "library/app", "v1")
+	writeTestImage(t, srcClient, "library/app", "v2")
+	writeTestImage(t, srcClient, "library/app", "v3")
+
+	// v1、v2 已推送；v2 的记录与目标 digest 不一致 (如被其他人覆盖)，v3 上次失败
+	m, dst := newTestMigrator(t, dstClient, journal.New(""))
+	jobs := make(map[string]migrateJob)
+	for _, tag := range []string{"v1", "v2", "v3"} {
+		jobs[tag] = migrateJob{srcClient: srcClient, img: config.ImageEntry{Name: "library/app"}, dstName: "library/app", tag: tag}
+	}
+	for _, tag := range []string{"v1", "v2"} {
+		m.run(ctx, jobs[tag])
+	}
+	v1Digest, err := dstClient.GetDigest(ctx, "library/app", "v1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	j := journal.New("")
+	for tag, e := range map[string]journal.Entry{
+		"v1": {Status: journal.StatusSuccess, DestinationDigest: v1Digest},
+		"v2": {Status: journal.StatusSuccess, DestinationDigest: "sha256:" + strings.Repeat("0", 64)},
+		"v3": {Status: journal.StatusFailed},
+	} {
+		e.Source, e.Destination = jobs[tag].srcRef(), jobs[tag].dstRef(dst)
+		if err := j.Record(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	m, _ = newTestMigrator(t, dstClient, j)
+	for _, tag := range []string{"v1", "v2", "v3"} {
+		m.run(ctx, jobs[tag])
+	}
+
+	statuses := make(map[string]string)
+	for _, e := range m.report.Entries {
+		statuses[e.Destination] = e.Status
+	}
+	for tag, want := range map[string]string{"v1": report.StatusSkipped, "v2": report.StatusUpToDate, "v3": report.StatusSuccess} {
+		if got := statuses[jobs[tag].dstRef(dst)]; got != want {
+			t.Errorf("%s status = %s, want %s", tag, got, want)
+		}
+	}
+	if e, ok := j.Get(jobs["v3"].srcRef(), jobs["v3"].dstRef(dst)); !ok || e.Status != journal.StatusSuccess {
+		t.Errorf("v3 状态日志 = %+v", e)
+	}
+}
+
+func TestOpenJournal(t *testing.T) {
+	path := filepath.Join(t.TempDir(), journal.FileName)
+	j := journal.New(path)
+	if err := j.Record(journal.Entry{Source: "a", Destination: "b", Status: journal.StatusFailed}); err != nil {
+		t.Fatal(err)
+	}
+
+	resumed, err := openJournal(path, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := resumed.Get("a", "b"); !ok {
+		t.Errorf("--resume 应沿用已有记录")
+	}
+
+	reset, err := openJournal(path, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total, _ := reset.Counts(); total != 0 {
+		t.Errorf("未指定 --resume 时应重新开始，Counts = %d", total)
+	}
+}
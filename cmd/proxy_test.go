@@ -0,0 +1,28 @@
+package cmd
+
+import (
+	"ikl/pkg/config"
+	"testing"
+)
+
+func TestRegistryProxy(t *testing.T) {
+	old := proxy
+	defer func() { proxy = old }()
+
+	tests := []struct {
+		flag     string
+		registry string
+		want     string
+	}{
+		{flag: "", registry: "", want: ""},
+		{flag: "http://127.0.0.1:7890", registry: "", want: "http://127.0.0.1:7890"},
+		{flag: "http://127.0.0.1:7890", registry: "socks5://10.0.0.1:1080", want: "socks5://10.0.0.1:1080"},
+		{flag: "http://127.0.0.1:7890", registry: "direct", want: "direct"},
+	}
+	for _, tt := range tests {
+		proxy = tt.flag
+		if got := registryProxy(config.RegistryConfig{Proxy: tt.registry}); got != tt.want {
+			t.Errorf("registryProxy(--proxy=%q, proxy: %q) = %q, want %q", tt.flag, tt.registry, got, tt.want)
+		}
+	}
+}
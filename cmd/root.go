@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"fmt"
+	"ikl/pkg/config"
 	"os"
 
 	"github.com/spf13/cobra"
@@ -27,15 +28,25 @@ func Execute() error {
 
 func init() {
 	// 添加全局 Persistent Flag
-	rootCmd.PersistentFlags().StringVar(&proxy, "proxy", "", "HTTP/HTTPS 代理地址 (例如: http://127.0.0.1:7890)")
+	rootCmd.PersistentFlags().StringVar(&proxy, "proxy", "", "代理地址，支持 http/https/socks5/socks5h，direct 表示直连；未指定时使用环境变量 HTTP_PROXY/HTTPS_PROXY/ALL_PROXY (例如: http://127.0.0.1:7890)")
 	// 新增 flag
-	rootCmd.PersistentFlags().StringVar(&noProxy, "no-proxy", "", "不使用代理的主机列表，逗号分隔 (例如: ykl.io,localhost,127.0.0.1)")
+	rootCmd.PersistentFlags().StringVar(&noProxy, "no-proxy", "", "不使用代理的主机列表，逗号分隔，支持域名 (含子域名)、.域名、IP、CIDR 与端口；未指定时使用环境变量 NO_PROXY (例如: ykl.io,localhost,10.0.0.0/8)")
+}
+
+// logf 将提示信息输出到 stderr，保持 stdout 只包含命令结果，便于脚本解析
+func logf(format string, a ...interface{}) {
+	fmt.Fprint(os.Stderr, config.Redact(fmt.Sprintf(format, a...)))
+}
+
+// printf 与 fmt.Printf 相同，但会对配置中的密钥脱敏，用于可能包含错误信息的输出
+func printf(format string, a ...interface{}) {
+	fmt.Print(config.Redact(fmt.Sprintf(format, a...)))
 }
 
 // handleError 统一错误处理
 func handleError(err error) {
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "❌ 错误: %v\n", err)
+		fmt.Fprintf(os.Stderr, "❌ 错误: %s\n", config.Redact(err.Error()))
 		os.Exit(1)
 	}
 }
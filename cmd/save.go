@@ -0,0 +1,98 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"ikl/pkg/bundle"
+	"ikl/pkg/config"
+	"ikl/pkg/registry"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var saveOutput string
+
+var saveCmd = &cobra.Command{
+	Use:   "save",
+	Short: "将配置文件中的镜像导出为 OCI image layout 目录或 tar.gz，用于离线交付",
+	Long: `读取与 migrate 相同的配置文件 (image_list / images、#arch= 架构筛选、tag_filter)，
+将所有镜像写入同一个 OCI image layout。Manifest List 结构会被保留，多个镜像共享的层只保存一份。
+--output 以 .tar.gz 或 .tgz 结尾时打包为压缩文件，否则写入目录 (目录中已有 layout 时追加)。`,
+	Example: `  ikl save --config config.yaml --output bundle/
+  ikl save --config config.yaml --output bundle.tar.gz --proxy http://127.0.0.1:7890`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if saveOutput == "" {
+			handleError(fmt.Errorf("必须通过 --output 指定输出目录或 tar.gz 文件"))
+		}
+
+		cfg, err := config.LoadConfig(configPath)
+		handleError(err)
+
+		images, err := cfg.ResolveImages()
+		handleError(err)
+
+		retryPolicy, err := newRetryPolicy(cfg.Retry)
+		handleError(err)
+
+		fmt.Println("📦 开始导出镜像...")
+		printSourceRegistries(cfg, images)
+		fmt.Println("------------------------------------------------")
+
+		// 压缩包先写入临时目录，全部完成后再打包
+		dir := saveOutput
+		if bundle.IsArchive(saveOutput) {
+			dir, err = os.MkdirTemp("", "ikl-save-")
+			handleError(err)
+			defer os.RemoveAll(dir)
+		}
+
+		p, err := bundle.OpenLayout(dir)
+		handleError(err)
+
+		ctx := context.Background()
+		jobs, failures := resolveJobs(ctx, cfg, images, logf)
+		failed := len(failures)
+		success := 0
+
+		for _, job := range jobs {
+			fmt.Printf("⏳ 正在导出 %s -> %s ...\n", job.srcRef(), job.dstImage())
+
+			var src *registry.Source
+			_, err := retryPolicy.Do(ctx, "导出 "+job.srcRef(), logf, func() (err error) {
+				src, err = job.resolveSource(ctx, cfg.Artifacts.Attestations)
+				if err != nil {
+					return err
+				}
+				return src.WriteLayout(p, job.dstName, job.dstIdent())
+			})
+			if err != nil {
+				printf("   ❌ 失败: %v\n", err)
+				failed++
+				continue
+			}
+
+			platformStr := ""
+			if len(src.Platforms) > 0 {
+				platformStr = " [" + strings.Join(src.Platforms, ", ") + "]"
+			}
+			fmt.Printf("   ✅ 完成 %s%s\n", registry.ShortDigest(src.Digest), platformStr)
+			success++
+		}
+
+		if bundle.IsArchive(saveOutput) {
+			fmt.Printf("🗜️  正在打包 %s ...\n", saveOutput)
+			handleError(bundle.Archive(dir, saveOutput))
+		}
+
+		fmt.Println("------------------------------------------------")
+		fmt.Printf("🎉 导出结束。成功: %d, 失败: %d, 输出: %s\n", success, failed, saveOutput)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(saveCmd)
+	saveCmd.Flags().StringVarP(&configPath, "config", "c", "config.yaml", "配置文件路径 (与 migrate 相同)")
+	saveCmd.Flags().StringVarP(&saveOutput, "output", "o", "", "输出的 OCI layout 目录，或 .tar.gz/.tgz 文件")
+}
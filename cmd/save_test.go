@@ -0,0 +1,135 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"ikl/pkg/registry"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+)
+
+// writePlatformImage 向仓库推送指定平台的镜像，多个平台时推送 Manifest List
+func writePlatformImage(t *testing.T, c *registry.Client, ref string, platforms ...string) {
+	t.Helper()
+	r, err := name.ParseReference(c.URL+"/"+ref, name.Insecure)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var adds []mutate.IndexAddendum
+	for _, p := range platforms {
+		plat, err := v1.ParsePlatform(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		img, err := random.Image(256, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cf, err := img.ConfigFile()
+		if err != nil {
+			t.Fatal(err)
+		}
+		cf = cf.DeepCopy()
+		cf.OS, cf.Architecture, cf.Variant = plat.OS, plat.Architecture, plat.Variant
+		if img, err = mutate.ConfigFile(img, cf); err != nil {
+			t.Fatal(err)
+		}
+		adds = append(adds, mutate.IndexAddendum{Add: img, Descriptor: v1.Descriptor{Platform: plat}})
+	}
+
+	if len(adds) == 1 {
+		err = remote.Write(r, adds[0].Add.(v1.Image))
+	} else {
+		err = remote.WriteIndex(r, mutate.AppendManifests(empty.Index, adds...))
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestSaveLoad ikl save 导出的离线包经 ikl load 导入后与源镜像 digest 一致
+func TestSaveLoad(t *testing.T) {
+	for _, output := range []string{"bundle", "bundle.tar.gz"} {
+		t.Run(output, func(t *testing.T) {
+			ctx := context.Background()
+			srcClient := newTestClient(t)
+			dstClient := newTestClient(t)
+			writePlatformImage(t, srcClient, "library/app:v1", "linux/amd64")
+			writePlatformImage(t, srcClient, "library/app:v2", "linux/amd64", "linux/arm64", "linux/s390x")
+
+			dir := t.TempDir()
+			cfgPath := filepath.Join(dir, "config.yaml")
+			cfg := fmt.Sprintf(`source_registries:
+  "%[1]s":
+    insecure: true
+destination_registries:
+  "%[2]s":
+    insecure: true
+retry:
+  attempts: 1
+image_list: |
+  %[1]s/library/app:v1
+  %[1]s/library/app:v2
+`, srcClient.URL, dstClient.URL)
+			if err := os.WriteFile(cfgPath, []byte(cfg), 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			oldConfig, oldOutput, oldFrom, oldPrefix := configPath, saveOutput, loadFrom, loadPrefix
+			defer func() {
+				configPath, saveOutput, loadFrom, loadPrefix = oldConfig, oldOutput, oldFrom, oldPrefix
+			}()
+			configPath = cfgPath
+			saveOutput = filepath.Join(dir, output)
+			loadFrom = saveOutput
+			loadPrefix = "team-a"
+
+			saveCmd.Run(saveCmd, nil)
+			loadCmd.Run(loadCmd, nil)
+
+			// 单架构镜像原样导入
+			want, err := srcClient.GetDigest(ctx, "library/app", "v1")
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := dstClient.GetDigest(ctx, "team-a/library/app", "v1")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != want {
+				t.Errorf("team-a/library/app:v1 digest = %q, want %q", got, want)
+			}
+
+			// Manifest List 保留筛选后的默认架构
+			ref, err := name.ParseReference(dstClient.URL+"/team-a/library/app:v2", name.Insecure)
+			if err != nil {
+				t.Fatal(err)
+			}
+			idx, err := remote.Index(ref)
+			if err != nil {
+				t.Fatal(err)
+			}
+			manifest, err := idx.IndexManifest()
+			if err != nil {
+				t.Fatal(err)
+			}
+			var platforms []string
+			for _, desc := range manifest.Manifests {
+				platforms = append(platforms, desc.Platform.String())
+			}
+			if strings.Join(platforms, ",") != "linux/amd64,linux/arm64" {
+				t.Errorf("team-a/library/app:v2 platforms = %v, want [linux/amd64 linux/arm64]", platforms)
+			}
+		})
+	}
+}
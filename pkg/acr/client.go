@@ -0,0 +1,284 @@
+package acr
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"ikl/pkg/httptransport"
+	"ikl/pkg/tlsutil"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// apiVersion 是阿里云容器镜像服务 (个人版) OpenAPI 的版本
+const apiVersion = "2016-06-07"
+
+type Client struct {
+	Endpoint        string
+	AccessKeyID     string
+	AccessKeySecret string
+	Client          *http.Client
+}
+
+// NewClient 创建阿里云容器镜像服务 OpenAPI 客户端
+// endpoint: 为空时根据 region 生成，如 "https://cr.cn-hangzhou.aliyuncs.com"；可指向本地 HTTP 服务用于测试
+func NewClient(endpoint, region, accessKeyID, accessKeySecret string, tlsOpts tlsutil.Options, proxyURL string, noProxy string) (*Client, error) {
+	if endpoint == "" {
+		if region == "" {
+			return nil, fmt.Errorf("未配置 region，且无法从仓库地址推断")
+		}
+		endpoint = fmt.Sprintf("https://cr.%s.aliyuncs.com", region)
+	}
+	if !strings.HasPrefix(endpoint, "http://") && !strings.HasPrefix(endpoint, "https://") {
+		endpoint = "https://" + endpoint
+	}
+	endpoint = strings.TrimSuffix(endpoint, "/")
+
+	transport, err := httptransport.New(httptransport.Options{
+		TLS:     tlsOpts,
+		Proxy:   proxyURL,
+		NoProxy: noProxy,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &Client{
+		Endpoint:        endpoint,
+		AccessKeyID:     accessKeyID,
+		AccessKeySecret: accessKeySecret,
+		Client: &http.Client{
+			Transport: transport,
+			Timeout:   10 * time.Second,
+		},
+	}, nil
+}
+
+// RegionFromRegistry 从 ACR 仓库地址推断地域
+// 例如 "registry.cn-hangzhou.aliyuncs.com" 或 "registry-vpc.cn-hangzhou.aliyuncs.com" -> "cn-hangzhou"
+func RegionFromRegistry(registry string) string {
+	host := strings.Split(registry, ":")[0]
+	parts := strings.Split(host, ".")
+	if len(parts) == 4 && strings.HasPrefix(parts[0], "registry") && parts[2] == "aliyuncs" && parts[3] == "com" {
+		return parts[1]
+	}
+	return ""
+}
+
+// RepositoryName 将目标镜像名称改写到指定命名空间下
+// ACR 仓库名只有 命名空间/仓库 两级，因此仅保留原名称的最后一段，例如 "sig-storage/csi-attacher" -> "ns/csi-attacher"
+func RepositoryName(namespace, name string) string {
+	return namespace + "/" + path.Base(name)
+}
+
+// EnsureNamespace 检查命名空间是否存在，不存在则创建
+func (c *Client) EnsureNamespace(namespace string) error {
+	status, body, err := c.do("GET", "/namespace/"+url.PathEscape(namespace), nil)
+	if err != nil {
+		return fmt.Errorf("检查命名空间 %s 失败: %w", namespace, err)
+	}
+	if status == http.StatusOK {
+		return nil
+	}
+	if status != http.StatusNotFound {
+		return fmt.Errorf("检查命名空间 %s 失败: %s", namespace, apiError(status, body))
+	}
+
+	fmt.Printf("✨ 目标 ACR 命名空间 '%s' 不存在，正在自动创建...\n", namespace)
+	payload := map[string]interface{}{
+		"Namespace": map[string]string{
+			"Namespace": namespace,
+		},
+	}
+	status, body, err = c.do("PUT", "/namespace", payload)
+	if err != nil {
+		return fmt.Errorf("创建命名空间 %s 失败: %w", namespace, err)
+	}
+	if status == http.StatusOK || alreadyExists(status, body) {
+		return nil
+	}
+	return fmt.Errorf("创建命名空间 %s 失败: %s", namespace, apiError(status, body))
+}
+
+// EnsureRepository 检查镜像仓库是否存在，不存在则创建为私有仓库
+func (c *Client) EnsureRepository(namespace, repo string) error {
+	status, body, err := c.do("GET", fmt.Sprintf("/repos/%s/%s", url.PathEscape(namespace), url.PathEscape(repo)), nil)
+	if err != nil {
+		return fmt.Errorf("检查镜像仓库 %s/%s 失败: %w", namespace, repo, err)
+	}
+	if status == http.StatusOK {
+		return nil
+	}
+	if status != http.StatusNotFound {
+		return fmt.Errorf("检查镜像仓库 %s/%s 失败: %s", namespace, repo, apiError(status, body))
+	}
+
+	fmt.Printf("✨ 目标 ACR 镜像仓库 '%s/%s' 不存在，正在自动创建...\n", namespace, repo)
+	payload := map[string]interface{}{
+		"Repo": map[string]string{
+			"RepoNamespace": namespace,
+			"RepoName":      repo,
+			"Summary":       "created by ikl",
+			"RepoType":      "PRIVATE", // 默认创建为私有仓库
+		},
+	}
+	status, body, err = c.do("PUT", "/repos", payload)
+	if err != nil {
+		return fmt.Errorf("创建镜像仓库 %s/%s 失败: %w", namespace, repo, err)
+	}
+	if status == http.StatusOK || alreadyExists(status, body) {
+		return nil
+	}
+	return fmt.Errorf("创建镜像仓库 %s/%s 失败: %s", namespace, repo, apiError(status, body))
+}
+
+// SetRepositoryPublic 设置镜像仓库的可见性 (PUBLIC/PRIVATE)
+// 更新接口要求同时提交摘要，因此先读取仓库当前的摘要原样提交，避免覆盖用户维护的内容
+func (c *Client) SetRepositoryPublic(namespace, repo string, public bool) error {
+	repoType := "PRIVATE"
+	if public {
+		repoType = "PUBLIC"
+	}
+	resource := fmt.Sprintf("/repos/%s/%s", url.PathEscape(namespace), url.PathEscape(repo))
+
+	status, body, err := c.do("GET", resource, nil)
+	if err != nil {
+		return fmt.Errorf("读取镜像仓库 %s/%s 失败: %w", namespace, repo, err)
+	}
+	if status != http.StatusOK {
+		return fmt.Errorf("读取镜像仓库 %s/%s 失败: %s", namespace, repo, apiError(status, body))
+	}
+	var current struct {
+		Data struct {
+			Repo struct {
+				Summary  string `json:"summary"`
+				RepoType string `json:"repoType"`
+			} `json:"repo"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &current); err != nil {
+		return fmt.Errorf("解析镜像仓库 %s/%s 信息失败: %w", namespace, repo, err)
+	}
+	if strings.EqualFold(current.Data.Repo.RepoType, repoType) {
+		return nil
+	}
+
+	payload := map[string]interface{}{
+		"Repo": map[string]string{
+			"Summary":  current.Data.Repo.Summary,
+			"RepoType": repoType,
+		},
+	}
+	status, body, err = c.do("POST", resource, payload)
+	if err != nil {
+		return fmt.Errorf("设置镜像仓库 %s/%s 可见性失败: %w", namespace, repo, err)
+	}
+	if status == http.StatusOK {
+		return nil
+	}
+	return fmt.Errorf("设置镜像仓库 %s/%s 可见性失败: %s", namespace, repo, apiError(status, body))
+}
+
+// do 发送经过 ROA 签名的请求，返回状态码与响应体
+func (c *Client) do(method, resource string, payload interface{}) (int, []byte, error) {
+	var body []byte
+	if payload != nil {
+		var err error
+		if body, err = json.Marshal(payload); err != nil {
+			return 0, nil, err
+		}
+	}
+
+	req, err := http.NewRequest(method, c.Endpoint+resource, bytes.NewReader(body))
+	if err != nil {
+		return 0, nil, err
+	}
+	c.sign(req, body)
+
+	resp, err := c.Client.Do(req)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, nil, err
+	}
+	return resp.StatusCode, respBody, nil
+}
+
+// sign 按阿里云 ROA 风格 (HMAC-SHA1) 为请求添加公共头与 Authorization 签名
+func (c *Client) sign(req *http.Request, body []byte) {
+	nonce := make([]byte, 16)
+	_, _ = rand.Read(nonce)
+
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Date", time.Now().UTC().Format(http.TimeFormat))
+	req.Header.Set("x-acs-version", apiVersion)
+	req.Header.Set("x-acs-signature-method", "HMAC-SHA1")
+	req.Header.Set("x-acs-signature-version", "1.0")
+	req.Header.Set("x-acs-signature-nonce", hex.EncodeToString(nonce))
+	if len(body) > 0 {
+		sum := md5.Sum(body)
+		req.Header.Set("Content-Type", "application/json;charset=utf-8")
+		req.Header.Set("Content-MD5", base64.StdEncoding.EncodeToString(sum[:]))
+	}
+
+	// 规范化的 x-acs-* 头
+	var acsHeaders []string
+	for key := range req.Header {
+		lower := strings.ToLower(key)
+		if strings.HasPrefix(lower, "x-acs-") {
+			acsHeaders = append(acsHeaders, lower+":"+req.Header.Get(key))
+		}
+	}
+	sort.Strings(acsHeaders)
+
+	// 规范化的资源路径 (含排序后的查询参数)
+	resource := req.URL.EscapedPath()
+	if query := req.URL.Query(); len(query) > 0 {
+		resource += "?" + query.Encode()
+	}
+
+	stringToSign := strings.Join([]string{
+		req.Method,
+		req.Header.Get("Accept"),
+		req.Header.Get("Content-MD5"),
+		req.Header.Get("Content-Type"),
+		req.Header.Get("Date"),
+	}, "\n") + "\n" + strings.Join(acsHeaders, "\n") + "\n" + resource
+
+	mac := hmac.New(sha1.New, []byte(c.AccessKeySecret))
+	mac.Write([]byte(stringToSign))
+	signature := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+	req.Header.Set("Authorization", fmt.Sprintf("acs %s:%s", c.AccessKeyID, signature))
+}
+
+// alreadyExists 判断创建请求是否因资源已存在而失败 (并发或刚创建，视为成功)
+func alreadyExists(status int, body []byte) bool {
+	return status == http.StatusConflict || strings.Contains(strings.ToUpper(string(body)), "ALREADY_EXIST")
+}
+
+// apiError 提取 OpenAPI 错误响应中的 code 与 message
+func apiError(status int, body []byte) string {
+	var e struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal(body, &e); err == nil && e.Code != "" {
+		return fmt.Sprintf("API 响应错误: %d, %s: %s", status, e.Code, e.Message)
+	}
+	return fmt.Sprintf("API 响应错误: %d, Body: %s", status, string(body))
+}
@@ -0,0 +1,276 @@
+package acr
+
+import (
+	"encoding/json"
+	"encoding/pem"
+	"ikl/pkg/tlsutil"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeACR 是 ACR OpenAPI 的本地替身，记录收到的请求并按路径返回预设响应
+type fakeACR struct {
+	mu         sync.Mutex
+	namespaces map[string]bool
+	repos      map[string]*fakeRepo
+	requests   []string
+	fail       map[string]int // 按 "方法 路径" 返回的错误状态码
+}
+
+type fakeRepo struct {
+	Summary  string `json:"summary"`
+	RepoType string `json:"repoType"`
+}
+
+func (f *fakeACR) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	key := r.Method + " " + r.URL.Path
+	f.requests = append(f.requests, key)
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "acs test-id:") || r.Header.Get("x-acs-version") != apiVersion {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	if status, ok := f.fail[key]; ok {
+		w.WriteHeader(status)
+		_, _ = io.WriteString(w, `{"code":"NAMESPACE_LIMIT_EXCEED","message":"namespace quota exceeded"}`)
+		return
+	}
+
+	var body map[string]map[string]string
+	if r.Body != nil {
+		_ = json.NewDecoder(r.Body).Decode(&body)
+	}
+
+	switch {
+	case r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, "/namespace/"):
+		if !f.namespaces[strings.TrimPrefix(r.URL.Path, "/namespace/")] {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	case r.Method == http.MethodPut && r.URL.Path == "/namespace":
+		f.namespaces[body["Namespace"]["Namespace"]] = true
+	case r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, "/repos/"):
+		repo, ok := f.repos[strings.TrimPrefix(r.URL.Path, "/repos/")]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"data": map[string]interface{}{"repo": repo}})
+	case r.Method == http.MethodPut && r.URL.Path == "/repos":
+		f.repos[body["Repo"]["RepoNamespace"]+"/"+body["Repo"]["RepoName"]] = &fakeRepo{
+			Summary:  body["Repo"]["Summary"],
+			RepoType: body["Repo"]["RepoType"],
+		}
+	case r.Method == http.MethodPost && strings.HasPrefix(r.URL.Path, "/repos/"):
+		repo, ok := f.repos[strings.TrimPrefix(r.URL.Path, "/repos/")]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		repo.Summary, repo.RepoType = body["Repo"]["Summary"], body["Repo"]["RepoType"]
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+	}
+}
+
+func newFakeACR(t *testing.T) (*fakeACR, *Client) {
+	t.Helper()
+	f := &fakeACR{namespaces: map[string]bool{}, repos: map[string]*fakeRepo{}, fail: map[string]int{}}
+	srv := httptest.NewServer(f)
+	t.Cleanup(srv.Close)
+
+	c, err := NewClient(srv.URL, "", "test-id", "test-secret", tlsutil.Options{}, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return f, c
+}
+
+func TestEnsureNamespace(t *testing.T) {
+	f, c := newFakeACR(t)
+
+	if err := c.EnsureNamespace("mirror"); err != nil {
+		t.Fatal(err)
+	}
+	if !f.namespaces["mirror"] {
+		t.Fatal("命名空间不存在时应创建")
+	}
+	want := []string{"GET /namespace/mirror", "PUT /namespace"}
+	if strings.Join(f.requests, ",") != strings.Join(want, ",") {
+		t.Errorf("requests = %v, want %v", f.requests, want)
+	}
+
+	// 已存在时只检查不创建
+	f.requests = nil
+	if err := c.EnsureNamespace("mirror"); err != nil {
+		t.Fatal(err)
+	}
+	if len(f.requests) != 1 {
+		t.Errorf("requests = %v, want 仅 GET", f.requests)
+	}
+}
+
+func TestEnsureRepository(t *testing.T) {
+	f, c := newFakeACR(t)
+
+	if err := c.EnsureRepository("mirror", "nginx"); err != nil {
+		t.Fatal(err)
+	}
+	if f.repos["mirror/nginx"] == nil {
+		t.Fatal("镜像仓库不存在时应创建")
+	}
+	if got := f.repos["mirror/nginx"].RepoType; got != "PRIVATE" {
+		t.Errorf("RepoType = %q, want PRIVATE", got)
+	}
+
+	f.requests = nil
+	if err := c.EnsureRepository("mirror", "nginx"); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"GET /repos/mirror/nginx"}; strings.Join(f.requests, ",") != strings.Join(want, ",") {
+		t.Errorf("requests = %v, want %v", f.requests, want)
+	}
+}
+
+func TestSetRepositoryPublic(t *testing.T) {
+	f, c := newFakeACR(t)
+	f.repos["mirror/nginx"] = &fakeRepo{Summary: "maintained by platform team", RepoType: "PRIVATE"}
+
+	if err := c.SetRepositoryPublic("mirror", "nginx", true); err != nil {
+		t.Fatal(err)
+	}
+	repo := f.repos["mirror/nginx"]
+	if repo.RepoType != "PUBLIC" {
+		t.Errorf("RepoType = %q, want PUBLIC", repo.RepoType)
+	}
+	if repo.Summary != "maintained by platform team" {
+		t.Errorf("Summary = %q, 不应覆盖已有摘要", repo.Summary)
+	}
+
+	// 可见性已符合时不再更新
+	f.requests = nil
+	if err := c.SetRepositoryPublic("mirror", "nginx", true); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"GET /repos/mirror/nginx"}; strings.Join(f.requests, ",") != strings.Join(want, ",") {
+		t.Errorf("requests = %v, want %v", f.requests, want)
+	}
+
+	f.fail["POST /repos/mirror/nginx"] = http.StatusInternalServerError
+	if err := c.SetRepositoryPublic("mirror", "nginx", false); err == nil || !strings.Contains(err.Error(), "设置镜像仓库 mirror/nginx 可见性失败") {
+		t.Errorf("err = %v, want 设置可见性失败", err)
+	}
+}
+
+func TestAPIErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		fail string
+		call func(c *Client) error
+		want string
+	}{
+		{
+			name: "检查命名空间失败",
+			fail: "GET /namespace/mirror",
+			call: func(c *Client) error { return c.EnsureNamespace("mirror") },
+			want: "检查命名空间 mirror 失败: API 响应错误: 500, NAMESPACE_LIMIT_EXCEED: namespace quota exceeded",
+		},
+		{
+			name: "创建命名空间失败",
+			fail: "PUT /namespace",
+			call: func(c *Client) error { return c.EnsureNamespace("mirror") },
+			want: "创建命名空间 mirror 失败",
+		},
+		{
+			name: "创建镜像仓库失败",
+			fail: "PUT /repos",
+			call: func(c *Client) error { return c.EnsureRepository("mirror", "nginx") },
+			want: "创建镜像仓库 mirror/nginx 失败",
+		},
+		{
+			name: "读取镜像仓库失败",
+			fail: "GET /repos/mirror/nginx",
+			call: func(c *Client) error { return c.SetRepositoryPublic("mirror", "nginx", true) },
+			want: "读取镜像仓库 mirror/nginx 失败",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, c := newFakeACR(t)
+			f.fail[tt.fail] = http.StatusInternalServerError
+			err := tt.call(c)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("err = %v, want 包含 %q", err, tt.want)
+			}
+		})
+	}
+
+	// 签名错误时服务端拒绝请求
+	_, c := newFakeACR(t)
+	c.AccessKeyID = "other"
+	if err := c.EnsureNamespace("mirror"); err == nil || !strings.Contains(err.Error(), "401") {
+		t.Errorf("err = %v, want 401", err)
+	}
+}
+
+// TestNewClientTLS OpenAPI 请求使用仓库配置的 CA 证书
+func TestNewClientTLS(t *testing.T) {
+	f := &fakeACR{namespaces: map[string]bool{"mirror": true}, repos: map[string]*fakeRepo{}, fail: map[string]int{}}
+	srv := httptest.NewTLSServer(f)
+	defer srv.Close()
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := NewClient(srv.URL, "", "test-id", "test-secret", tlsutil.Options{}, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.EnsureNamespace("mirror"); err == nil || !strings.Contains(err.Error(), "certificate") {
+		t.Errorf("未信任服务端证书时 err = %v, want 证书校验失败", err)
+	}
+
+	c, err = NewClient(srv.URL, "", "test-id", "test-secret", tlsutil.Options{CAFile: caFile}, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.EnsureNamespace("mirror"); err != nil {
+		t.Errorf("配置 ca_file 后 err = %v", err)
+	}
+
+	if _, err := NewClient(srv.URL, "", "test-id", "test-secret", tlsutil.Options{CertFile: caFile}, "", ""); err == nil {
+		t.Error("只配置 cert_file 时应返回错误")
+	}
+}
+
+func TestAlreadyExists(t *testing.T) {
+	f, c := newFakeACR(t)
+	f.fail["PUT /repos"] = http.StatusConflict
+	if err := c.EnsureRepository("mirror", "nginx"); err != nil {
+		t.Errorf("并发创建导致的已存在错误应视为成功: %v", err)
+	}
+}
+
+func TestRegionFromRegistry(t *testing.T) {
+	tests := map[string]string{
+		"registry.cn-hangzhou.aliyuncs.com":             "cn-hangzhou",
+		"registry-vpc.cn-shanghai.aliyuncs.com":         "cn-shanghai",
+		"registry.cn-hangzhou.aliyuncs.com:443":         "cn-hangzhou",
+		"crpi-xxx.cn-hangzhou.personal.cr.aliyuncs.com": "",
+		"ykl.io:40443": "",
+	}
+	for registry, want := range tests {
+		if got := RegionFromRegistry(registry); got != want {
+			t.Errorf("RegionFromRegistry(%q) = %q, want %q", registry, got, want)
+		}
+	}
+}
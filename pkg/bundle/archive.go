@@ -0,0 +1,95 @@
+package bundle
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/layout"
+)
+
+// IsArchive 判断输出/输入路径是否为 tar.gz 压缩包
+func IsArchive(path string) bool {
+	return strings.HasSuffix(path, ".tar.gz") || strings.HasSuffix(path, ".tgz")
+}
+
+// OpenLayout 打开目录中的 OCI image layout，目录不存在或为空时新建
+func OpenLayout(dir string) (layout.Path, error) {
+	if _, err := os.Stat(filepath.Join(dir, "index.json")); err == nil {
+		return layout.FromPath(dir)
+	}
+	p, err := layout.Write(dir, empty.Index)
+	if err != nil {
+		return "", fmt.Errorf("初始化 OCI layout %s 失败: %w", dir, err)
+	}
+	return p, nil
+}
+
+// Archive 将 dir 目录打包为 tar.gz 写入 dest，先写临时文件再重命名，避免留下不完整的压缩包
+func Archive(dir, dest string) error {
+	tmp, err := os.CreateTemp(filepath.Dir(dest), ".ikl-bundle-")
+	if err != nil {
+		return fmt.Errorf("创建压缩包失败: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := writeTarGz(tmp, dir); err != nil {
+		tmp.Close()
+		return fmt.Errorf("写入压缩包失败: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), dest)
+}
+
+func writeTarGz(w io.Writer, dir string) error {
+	gw := gzip.NewWriter(w)
+	tw := tar.NewWriter(gw)
+
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil || rel == "." {
+			return err
+		}
+
+		hdr, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		hdr.Name = filepath.ToSlash(rel)
+		if info.IsDir() {
+			hdr.Name += "/"
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		_, err = io.Copy(tw, f)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gw.Close()
+}
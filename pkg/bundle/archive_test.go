@@ -0,0 +1,164 @@
+package bundle
+
+import (
+	"ikl/pkg/registry"
+	"path/filepath"
+	"testing"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+)
+
+// imageDigests 返回离线包中 镜像名:Tag -> digest 的对应关系，Manifest List 以 "index " 为前缀
+func imageDigests(t *testing.T, b *Bundle) map[string]string {
+	t.Helper()
+	got := make(map[string]string)
+	for _, img := range b.Images {
+		var (
+			digest v1.Hash
+			err    error
+			kind   string
+		)
+		if img.Index != nil {
+			digest, err = img.Index.Digest()
+			kind = "index "
+		} else {
+			digest, err = img.Image.Digest()
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		got[img.Repo+":"+img.Tag] = kind + digest.String()
+	}
+	return got
+}
+
+func TestSaveLoadRoundTrip(t *testing.T) {
+	img, err := random.Image(256, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	idx, err := random.Index(256, 1, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pinned, err := random.Image(256, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	imgSrc, err := registry.NewImageSource(img)
+	if err != nil {
+		t.Fatal(err)
+	}
+	idxSrc, err := registry.NewIndexSource(idx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pinnedSrc, err := registry.NewImageSource(pinned)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]string{
+		"library/app:v1":                     imgSrc.Digest,
+		"team-a/multi:v2":                    "index " + idxSrc.Digest,
+		"library/pinned:" + pinnedSrc.Digest: pinnedSrc.Digest,
+	}
+
+	for _, output := range []string{"bundle", "bundle.tar.gz", "bundle.tgz"} {
+		t.Run(output, func(t *testing.T) {
+			tmp := t.TempDir()
+			out := filepath.Join(tmp, output)
+			dir := out
+			if IsArchive(out) {
+				dir = filepath.Join(tmp, "layout")
+			}
+
+			p, err := OpenLayout(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := imgSrc.WriteLayout(p, "library/app", "v1"); err != nil {
+				t.Fatal(err)
+			}
+			if err := idxSrc.WriteLayout(p, "team-a/multi", "v2"); err != nil {
+				t.Fatal(err)
+			}
+
+			// 再次打开已有的 layout 时追加，而不是覆盖
+			p, err = OpenLayout(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := pinnedSrc.WriteLayout(p, "library/pinned", pinnedSrc.Digest); err != nil {
+				t.Fatal(err)
+			}
+			// 同名镜像重复导出时替换原有条目
+			if err := imgSrc.WriteLayout(p, "library/app", "v1"); err != nil {
+				t.Fatal(err)
+			}
+
+			if IsArchive(out) {
+				if err := Archive(dir, out); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			b, err := Open(out)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer b.Close()
+
+			if b.Format != "oci" {
+				t.Errorf("Format = %q, want oci", b.Format)
+			}
+			if len(b.Skipped) != 0 {
+				t.Errorf("Skipped = %v, want none", b.Skipped)
+			}
+			got := imageDigests(t, b)
+			if len(got) != len(want) || len(b.Images) != len(want) {
+				t.Fatalf("images = %v, want %v", got, want)
+			}
+			for ref, digest := range want {
+				if got[ref] != digest {
+					t.Errorf("%s = %q, want %q", ref, got[ref], digest)
+				}
+			}
+
+			// 读取镜像内容，确认层数据完整
+			for _, img := range b.Images {
+				if img.Image == nil {
+					continue
+				}
+				layers, err := img.Image.Layers()
+				if err != nil {
+					t.Fatal(err)
+				}
+				for _, l := range layers {
+					rc, err := l.Compressed()
+					if err != nil {
+						t.Fatalf("%s:%s: %v", img.Repo, img.Tag, err)
+					}
+					rc.Close()
+				}
+			}
+		})
+	}
+}
+
+func TestArchiveLeavesNoPartialFile(t *testing.T) {
+	tmp := t.TempDir()
+	dest := filepath.Join(tmp, "bundle.tar.gz")
+	if err := Archive(filepath.Join(tmp, "missing"), dest); err == nil {
+		t.Fatal("Archive() of a missing directory succeeded")
+	}
+	matches, err := filepath.Glob(filepath.Join(tmp, "*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(matches) != 0 {
+		t.Errorf("files left behind: %v", matches)
+	}
+}
@@ -0,0 +1,294 @@
+package bundle
+
+import (
+	"archive/tar"
+	"bufio"
+	"compress/gzip"
+	"fmt"
+	"ikl/pkg/registry"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/layout"
+	"github.com/google/go-containerregistry/pkg/v1/tarball"
+)
+
+// placeholderRegistry 用于解析不含仓库地址的镜像名，避免单段名称被补全为 library/xxx
+const placeholderRegistry = "registry.invalid"
+
+// Image 是离线包中的一个镜像，Image 与 Index 二选一
+type Image struct {
+	Repo  string // 镜像名称，不含仓库地址，如 library/app
+	Tag   string // Tag，或按 digest 固定的镜像的 digest (sha256:...)
+	Image v1.Image
+	Index v1.ImageIndex
+}
+
+// Bundle 是已打开的离线镜像包
+type Bundle struct {
+	Format  string   // "oci" (OCI image layout) 或 "docker" (docker save)
+	Images  []Image  // 可导入的镜像
+	Skipped []string // 因缺少镜像名称等原因无法导入的条目说明
+	tmpDir  string
+}
+
+// Close 清理解压产生的临时目录
+func (b *Bundle) Close() error {
+	if b.tmpDir == "" {
+		return nil
+	}
+	return os.RemoveAll(b.tmpDir)
+}
+
+// Open 打开离线镜像包，支持:
+//   - OCI image layout 目录 (ikl save 输出)
+//   - OCI image layout 的 .tar / .tar.gz / .tgz 压缩包
+//   - docker save 生成的 .tar / .tar.gz
+func Open(path string) (*Bundle, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("打开离线包失败: %w", err)
+	}
+	if info.IsDir() {
+		return readLayout(path)
+	}
+
+	opener := func() (io.ReadCloser, error) {
+		return openTar(path)
+	}
+
+	files, err := listTar(opener)
+	if err != nil {
+		return nil, fmt.Errorf("读取离线包 %s 失败: %w", path, err)
+	}
+
+	// 新版 docker save 同时包含 oci-layout 与 manifest.json，优先按 OCI layout 读取以保留 Manifest List
+	if files["oci-layout"] && files["index.json"] {
+		dir, err := os.MkdirTemp("", "ikl-load-")
+		if err != nil {
+			return nil, err
+		}
+		if err := extract(opener, dir); err != nil {
+			os.RemoveAll(dir)
+			return nil, fmt.Errorf("解压离线包 %s 失败: %w", path, err)
+		}
+		b, err := readLayout(dir)
+		if err != nil {
+			os.RemoveAll(dir)
+			return nil, err
+		}
+		b.tmpDir = dir
+		return b, nil
+	}
+
+	if files["manifest.json"] {
+		return readDockerTarball(opener)
+	}
+	return nil, fmt.Errorf("%s 既不是 OCI image layout 也不是 docker save 压缩包", path)
+}
+
+// readLayout 读取 OCI image layout 目录，镜像名称取自 index.json 中的注解
+func readLayout(dir string) (*Bundle, error) {
+	p, err := layout.FromPath(dir)
+	if err != nil {
+		return nil, fmt.Errorf("读取 OCI layout %s 失败: %w", dir, err)
+	}
+	idx, err := p.ImageIndex()
+	if err != nil {
+		return nil, fmt.Errorf("读取 OCI layout %s 失败: %w", dir, err)
+	}
+	manifest, err := idx.IndexManifest()
+	if err != nil {
+		return nil, fmt.Errorf("解析 %s/index.json 失败: %w", dir, err)
+	}
+
+	b := &Bundle{Format: "oci"}
+	for _, desc := range manifest.Manifests {
+		ref := desc.Annotations[registry.AnnotationImageName]
+		if ref == "" {
+			// 其他工具生成的 layout 可能只在 ref.name 中记录完整镜像名
+			if r := desc.Annotations[registry.AnnotationRefName]; strings.Contains(r, "/") {
+				ref = r
+			}
+		}
+		if ref == "" {
+			b.Skipped = append(b.Skipped, fmt.Sprintf("%s: 缺少镜像名称注解 %s", desc.Digest, registry.AnnotationImageName))
+			continue
+		}
+
+		repo, tag, err := splitName(ref)
+		if err != nil {
+			b.Skipped = append(b.Skipped, fmt.Sprintf("%s: %v", ref, err))
+			continue
+		}
+
+		img := Image{Repo: repo, Tag: tag}
+		switch {
+		case desc.MediaType.IsIndex():
+			img.Index, err = idx.ImageIndex(desc.Digest)
+		case desc.MediaType.IsImage():
+			img.Image, err = idx.Image(desc.Digest)
+		default:
+			err = fmt.Errorf("不支持的类型 %s", desc.MediaType)
+		}
+		if err != nil {
+			b.Skipped = append(b.Skipped, fmt.Sprintf("%s: %v", ref, err))
+			continue
+		}
+		b.Images = append(b.Images, img)
+	}
+	return b, nil
+}
+
+// readDockerTarball 读取 docker save 生成的压缩包，每个 RepoTags 条目对应一个镜像
+func readDockerTarball(opener tarball.Opener) (*Bundle, error) {
+	manifest, err := tarball.LoadManifest(opener)
+	if err != nil {
+		return nil, fmt.Errorf("解析 docker save manifest.json 失败: %w", err)
+	}
+
+	b := &Bundle{Format: "docker"}
+	for _, desc := range manifest {
+		if len(desc.RepoTags) == 0 {
+			b.Skipped = append(b.Skipped, fmt.Sprintf("%s: 没有 RepoTags", desc.Config))
+			continue
+		}
+		for _, repoTag := range desc.RepoTags {
+			// tarball.Image 按默认规则解析 RepoTags 进行匹配，这里也使用默认规则
+			tag, err := name.NewTag(repoTag)
+			if err != nil {
+				b.Skipped = append(b.Skipped, fmt.Sprintf("%s: %v", repoTag, err))
+				continue
+			}
+			repo, tagStr, err := splitName(repoTag)
+			if err != nil {
+				b.Skipped = append(b.Skipped, fmt.Sprintf("%s: %v", repoTag, err))
+				continue
+			}
+			img, err := tarball.Image(opener, &tag)
+			if err != nil {
+				b.Skipped = append(b.Skipped, fmt.Sprintf("%s: %v", repoTag, err))
+				continue
+			}
+			b.Images = append(b.Images, Image{Repo: repo, Tag: tagStr, Image: img})
+		}
+	}
+	return b, nil
+}
+
+// splitName 将 "docker.io/library/alpine:3.19" 或 "library/app:v1" 拆分为不含仓库地址的镜像名与 Tag
+// "library/app@sha256:..." 返回镜像名与 digest
+func splitName(ref string) (string, string, error) {
+	r, err := name.ParseReference(ref, name.WithDefaultRegistry(placeholderRegistry))
+	if err != nil {
+		return "", "", fmt.Errorf("无法解析镜像名称: %w", err)
+	}
+	return r.Context().RepositoryStr(), r.Identifier(), nil
+}
+
+// openTar 打开 tar 文件，gzip 压缩的文件会自动解压
+func openTar(path string) (io.ReadCloser, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	br := bufio.NewReader(f)
+	magic, _ := br.Peek(2)
+	if len(magic) == 2 && magic[0] == 0x1f && magic[1] == 0x8b {
+		gr, err := gzip.NewReader(br)
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		return &readCloser{Reader: gr, closers: []io.Closer{gr, f}}, nil
+	}
+	return &readCloser{Reader: br, closers: []io.Closer{f}}, nil
+}
+
+type readCloser struct {
+	io.Reader
+	closers []io.Closer
+}
+
+func (r *readCloser) Close() error {
+	var firstErr error
+	for _, c := range r.closers {
+		if err := c.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// listTar 返回 tar 包顶层的文件名集合
+func listTar(opener tarball.Opener) (map[string]bool, error) {
+	rc, err := opener()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	files := make(map[string]bool)
+	tr := tar.NewReader(rc)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return files, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		files[strings.TrimPrefix(filepath.ToSlash(filepath.Clean(hdr.Name)), "./")] = true
+	}
+}
+
+// extract 将 tar 包解压到 dir，拒绝指向 dir 之外的路径
+func extract(opener tarball.Opener, dir string) error {
+	rc, err := opener()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	tr := tar.NewReader(rc)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		target := filepath.Join(dir, filepath.Clean(hdr.Name))
+		if target != dir && !strings.HasPrefix(target, dir+string(os.PathSeparator)) {
+			return fmt.Errorf("非法的文件路径 %s", hdr.Name)
+		}
+
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0o755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+				return err
+			}
+			f, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+			if err != nil {
+				return err
+			}
+			if _, err := io.Copy(f, tr); err != nil {
+				f.Close()
+				return err
+			}
+			if err := f.Close(); err != nil {
+				return err
+			}
+		}
+	}
+}
@@ -0,0 +1,312 @@
+package bundle
+
+import (
+	"archive/tar"
+	"bytes"
+	"encoding/json"
+	"ikl/pkg/registry"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/layout"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/tarball"
+)
+
+// writeTar 将 dir 目录打包为未压缩的 tar，与 docker save 的输出一致
+func writeTar(t *testing.T, dir, dest string) {
+	t.Helper()
+	f, err := os.Create(dest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	tw := tar.NewWriter(f)
+	err = filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || !info.Mode().IsRegular() {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if err := tw.WriteHeader(&tar.Header{Name: filepath.ToSlash(rel), Mode: 0o644, Size: int64(len(data))}); err != nil {
+			return err
+		}
+		_, err = tw.Write(data)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// tarOpener 返回读取内存中 tar 包的 Opener，entries 依次为文件名与内容
+func tarOpener(t *testing.T, entries ...string) tarball.Opener {
+	t.Helper()
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for i := 0; i+1 < len(entries); i += 2 {
+		if err := tw.WriteHeader(&tar.Header{Name: entries[i], Mode: 0o644, Size: int64(len(entries[i+1]))}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(entries[i+1])); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(buf.Bytes())), nil
+	}
+}
+
+func TestOpenDockerSaveManifest(t *testing.T) {
+	img, err := random.Image(256, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	untagged, err := random.Image(256, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	digest, err := img.Digest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	untaggedDigest, err := untagged.Digest()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	refs := make(map[name.Reference]v1.Image)
+	for _, r := range []string{"alpine:3.19", "docker.io/library/alpine:latest", "registry.example.com/team-a/app:v1"} {
+		tag, err := name.NewTag(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		refs[tag] = img
+	}
+	// 按 digest 导出的镜像在 manifest.json 中没有 RepoTags
+	d, err := name.NewDigest("library/untagged@" + untaggedDigest.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	refs[d] = untagged
+
+	path := filepath.Join(t.TempDir(), "images.tar")
+	if err := tarball.MultiRefWriteToFile(path, refs); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Close()
+
+	if b.Format != "docker" {
+		t.Errorf("Format = %q, want docker", b.Format)
+	}
+	// 单段名称保持原样，不补全为 library/xxx
+	want := map[string]string{
+		"alpine:3.19":           digest.String(),
+		"library/alpine:latest": digest.String(),
+		"team-a/app:v1":         digest.String(),
+	}
+	got := imageDigests(t, b)
+	if len(got) != len(want) {
+		t.Fatalf("images = %v, want %v", got, want)
+	}
+	for ref, d := range want {
+		if got[ref] != d {
+			t.Errorf("%s = %q, want %q", ref, got[ref], d)
+		}
+	}
+	if len(b.Skipped) != 1 || !strings.Contains(b.Skipped[0], "没有 RepoTags") {
+		t.Errorf("Skipped = %v, want the untagged image", b.Skipped)
+	}
+}
+
+func TestOpenDockerSaveIndex(t *testing.T) {
+	// docker 25+ 的 docker save 在 manifest.json 之外同时输出 OCI layout，index.json 中记录完整镜像名
+	idx, err := random.Index(256, 1, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, err := random.Image(256, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	idxDigest, err := idx.Digest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	imgDigest, err := img.Digest()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	p, err := layout.Write(dir, empty.Index)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.AppendIndex(idx, layout.WithAnnotations(map[string]string{
+		registry.AnnotationImageName: "docker.io/library/multi:v2",
+		registry.AnnotationRefName:   "v2",
+	})); err != nil {
+		t.Fatal(err)
+	}
+	// 其他工具只写 ref.name 时使用其中的完整镜像名
+	if err := p.AppendImage(img, layout.WithAnnotations(map[string]string{
+		registry.AnnotationRefName: "registry.example.com/team-a/app:v1",
+	})); err != nil {
+		t.Fatal(err)
+	}
+	// 只有 Tag 的 ref.name 无法还原镜像名
+	if err := p.AppendImage(img, layout.WithAnnotations(map[string]string{
+		registry.AnnotationRefName: "v1",
+	})); err != nil {
+		t.Fatal(err)
+	}
+	manifest, err := json.Marshal([]tarball.Descriptor{{Config: "blobs/sha256/unused", RepoTags: []string{"library/multi:v2"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "manifest.json"), manifest, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "images.tar")
+	writeTar(t, dir, path)
+
+	b, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpDir := b.tmpDir
+	if b.Format != "oci" {
+		t.Errorf("Format = %q, want oci", b.Format)
+	}
+	want := map[string]string{
+		"library/multi:v2": "index " + idxDigest.String(),
+		"team-a/app:v1":    imgDigest.String(),
+	}
+	got := imageDigests(t, b)
+	if len(got) != len(want) {
+		t.Fatalf("images = %v, want %v", got, want)
+	}
+	for ref, d := range want {
+		if got[ref] != d {
+			t.Errorf("%s = %q, want %q", ref, got[ref], d)
+		}
+	}
+	if len(b.Skipped) != 1 || !strings.Contains(b.Skipped[0], registry.AnnotationImageName) {
+		t.Errorf("Skipped = %v, want the image without a name", b.Skipped)
+	}
+
+	if err := b.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(tmpDir); !os.IsNotExist(err) {
+		t.Errorf("temporary directory %s not removed: %v", tmpDir, err)
+	}
+}
+
+func TestOpenUnknownTar(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "other.tar")
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "README"), []byte("hello"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	writeTar(t, dir, path)
+
+	if _, err := Open(path); err == nil || !strings.Contains(err.Error(), "既不是 OCI image layout") {
+		t.Errorf("Open() error = %v, want unknown format error", err)
+	}
+}
+
+func TestExtract(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		wantErr bool
+	}{
+		{"regular", "blobs/sha256/abc", false},
+		{"dot prefix", "./index.json", false},
+		{"absolute", "/index.json", false},
+		{"parent", "../evil", true},
+		{"nested parent", "blobs/../../evil", true},
+		{"deep parent", "blobs/sha256/../../../../tmp/evil", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			dir := filepath.Join(root, "out")
+			if err := os.Mkdir(dir, 0o755); err != nil {
+				t.Fatal(err)
+			}
+
+			err := extract(tarOpener(t, tt.file, "data"), dir)
+			if tt.wantErr {
+				if err == nil || !strings.Contains(err.Error(), "非法的文件路径") {
+					t.Fatalf("extract(%q) error = %v, want path rejection", tt.file, err)
+				}
+				if _, err := os.Stat(filepath.Join(root, "evil")); !os.IsNotExist(err) {
+					t.Errorf("extract(%q) wrote outside the target directory", tt.file)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("extract(%q) error = %v", tt.file, err)
+			}
+			data, err := os.ReadFile(filepath.Join(dir, filepath.Clean(tt.file)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(data) != "data" {
+				t.Errorf("content = %q, want data", data)
+			}
+		})
+	}
+}
+
+func TestOpenRejectsPathTraversal(t *testing.T) {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for _, f := range []string{"oci-layout", "index.json", "../evil"} {
+		if err := tw.WriteHeader(&tar.Header{Name: f, Mode: 0o644, Size: 2}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte("{}")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "bundle.tar")
+	if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Open(path); err == nil || !strings.Contains(err.Error(), "非法的文件路径") {
+		t.Errorf("Open() error = %v, want path rejection", err)
+	}
+}
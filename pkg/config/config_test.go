@@ -0,0 +1,151 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeConfig 在临时目录中写入配置文件，返回其路径
+func writeConfig(t *testing.T, content string, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	path := filepath.Join(dir, "config.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadConfigSecrets(t *testing.T) {
+	t.Setenv("IKL_TEST_USER", "robot$ci")
+	t.Setenv("IKL_TEST_PASSWORD", "env-password-123")
+	t.Setenv("IKL_TEST_KEY_PASSWORD", "key-password-789")
+	path := writeConfig(t, `
+source_registries:
+  docker.io:
+    username: ${IKL_TEST_USER}
+    password_env: IKL_TEST_PASSWORD
+destination_registries:
+  ykl.io:40443:
+    username: admin
+    password_file: harbor.pass
+    ca_file: certs/ca.pem
+    sign:
+      key: cosign.key
+      password_env: IKL_TEST_KEY_PASSWORD
+      tlog: false
+`, map[string]string{"harbor.pass": "file-password-456\n"})
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	src := cfg.SourceRegistries["docker.io"]
+	if src.Username != "robot$ci" || src.Password != "env-password-123" {
+		t.Errorf("source = %s/%s", src.Username, src.Password)
+	}
+	dst := cfg.DestinationRegs["ykl.io:40443"]
+	if dst.Password != "file-password-456" {
+		t.Errorf("password_file 读取结果 = %q", dst.Password)
+	}
+
+	baseDir := filepath.Dir(path)
+	if want := filepath.Join(baseDir, "certs/ca.pem"); dst.CAFile != want {
+		t.Errorf("ca_file = %s, want %s", dst.CAFile, want)
+	}
+	if want := filepath.Join(baseDir, "cosign.key"); dst.Sign.Key != want {
+		t.Errorf("sign.key = %s, want %s", dst.Sign.Key, want)
+	}
+	if dst.Sign.Password != "key-password-789" {
+		t.Errorf("sign.password_env 读取结果 = %q", dst.Sign.Password)
+	}
+
+	out := Redact("auth env-password-123 file-password-456 key-password-789")
+	if strings.Contains(out, "password-") {
+		t.Errorf("密码未脱敏: %s", out)
+	}
+}
+
+func TestLoadConfigErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{
+			name: "未设置的环境变量",
+			content: `
+destination_registries:
+  ykl.io:
+    password: ${IKL_TEST_UNSET}
+`,
+			want: "destination_registries.ykl.io: 字段 password: 环境变量 IKL_TEST_UNSET 未设置",
+		},
+		{
+			name: "多个密码来源",
+			content: `
+destination_registries:
+  ykl.io:
+    password: a
+    password_env: B
+`,
+			want: "只能配置其中一个",
+		},
+		{
+			name: "签名缺少 key",
+			content: `
+destination_registries:
+  ykl.io:
+    sign: {}
+`,
+			want: "destination_registries.ykl.io: 字段 sign: 需要配置 key",
+		},
+		{
+			name: "签名未显式关闭透明日志",
+			content: `
+destination_registries:
+  ykl.io:
+    sign:
+      key: cosign.key
+`,
+			want: "destination_registries.ykl.io: 字段 sign: 签名不会上传到 Rekor 透明日志，需显式配置 tlog: false",
+		},
+		{
+			name: "签名密码环境变量未设置",
+			content: `
+destination_registries:
+  ykl.io:
+    sign:
+      key: cosign.key
+      password_env: IKL_TEST_UNSET
+      tlog: false
+`,
+			want: "destination_registries.ykl.io: 字段 sign: 字段 password_env: 环境变量 IKL_TEST_UNSET 未设置",
+		},
+		{
+			name: "签名校验规则无效",
+			content: `
+source_registries:
+  docker.io:
+    verify:
+      identity: dev@example.com
+`,
+			want: "source_registries.docker.io: 字段 verify: keyless 校验需要同时配置",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadConfig(writeConfig(t, tt.content, nil))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("err = %v, want 包含 %q", err, tt.want)
+			}
+		})
+	}
+}
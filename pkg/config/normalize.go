@@ -2,24 +2,32 @@ package config
 
 import (
 	"fmt"
+	"ikl/pkg/platform"
+	"ikl/pkg/tagfilter"
 	"strings"
 
 	"github.com/google/go-containerregistry/pkg/name"
 )
 
+// image_list 行尾支持的指令，如 "nginx@sha256:... #tag=1.25 #arch=amd64"
 const (
-	archDirectivePrefix = "#arch="
+	archDirective = "arch"
+	tagDirective  = "tag"
 )
 
 var defaultArchitectures = []string{"amd64", "arm64"}
 
-// ResolveImages parses image_list, applying default architectures and directives.
+// ResolveImages parses image_list and images, applying default architectures and directives.
 func (cfg *MigrateConfig) ResolveImages() ([]ImageEntry, error) {
 	entriesFromList, err := parseImageList(cfg.ImageList)
 	if err != nil {
 		return nil, err
 	}
-	return entriesFromList, nil
+	entriesFromSpecs, err := parseImageSpecs(cfg.Images)
+	if err != nil {
+		return nil, err
+	}
+	return append(entriesFromList, entriesFromSpecs...), nil
 }
 
 func parseImageList(raw string) ([]ImageEntry, error) {
@@ -32,40 +40,213 @@ func parseImageList(raw string) ([]ImageEntry, error) {
 			continue
 		}
 
-		archs := []string{}
-		if idx := strings.Index(line, archDirectivePrefix); idx >= 0 {
-			archPart := strings.TrimSpace(line[idx+len(archDirectivePrefix):])
-			if archPart != "" {
-				archPart = strings.SplitN(archPart, " ", 2)[0]
-				for _, arch := range strings.Split(archPart, ",") {
-					arch = strings.TrimSpace(arch)
-					if arch != "" {
-						archs = append(archs, arch)
-					}
-				}
-			}
-			line = strings.TrimSpace(line[:idx])
+		line, directives, err := parseDirectives(line)
+		if err != nil {
+			return nil, fmt.Errorf("解析 image_list 第 %d 行失败: %w", lineNumber+1, err)
 		}
-
 		if line == "" {
 			continue
 		}
+		archs := directives[archDirective]
 
 		ref, err := name.ParseReference(line)
 		if err != nil {
 			return nil, fmt.Errorf("解析 image_list 第 %d 行失败: %w", lineNumber+1, err)
 		}
 
-		if len(archs) == 0 {
-			archs = append([]string{}, defaultArchitectures...)
+		repo := ref.Context()
+		entry := ImageEntry{
+			Registry: repo.RegistryStr(),
+			Name:     repo.RepositoryStr(),
 		}
 
+		if digest, ok := ref.(name.Digest); ok {
+			// 按 digest 固定的镜像原样推送，架构筛选会改变 digest
+			if len(archs) > 0 {
+				return nil, fmt.Errorf("解析 image_list 第 %d 行失败: 按 digest 固定的镜像不能指定 #arch，否则推送的 digest 会改变", lineNumber+1)
+			}
+			entry.Digest = digest.DigestStr()
+
+			// 目标 Tag 取自 #tag=，其次是 repo:tag@sha256:... 中的 tag，都没有时仅按 digest 推送
+			tags := directives[tagDirective]
+			if len(tags) == 0 {
+				if tag, ok := explicitTag(line); ok {
+					tags = []string{tag}
+				}
+			}
+			for _, tag := range tags {
+				if _, err := name.NewTag(repo.Name() + ":" + tag); err != nil {
+					return nil, fmt.Errorf("解析 image_list 第 %d 行失败: 无效的 #tag %q: %w", lineNumber+1, tag, err)
+				}
+			}
+			entry.Tags = tags
+		} else {
+			if len(directives[tagDirective]) > 0 {
+				return nil, fmt.Errorf("解析 image_list 第 %d 行失败: #tag 仅用于按 digest 固定的镜像 (repo@sha256:...)", lineNumber+1)
+			}
+			if len(archs) == 0 {
+				archs = append([]string{}, defaultArchitectures...)
+			}
+			if _, err := platform.ParseAll(archs); err != nil {
+				return nil, fmt.Errorf("解析 image_list 第 %d 行失败: #arch: %w", lineNumber+1, err)
+			}
+			entry.Tags = []string{ref.Identifier()}
+			entry.Architectures = archs
+		}
+
+		results = append(results, entry)
+	}
+
+	return results, nil
+}
+
+// parseDirectives 拆分镜像地址与行尾的 #key=v1,v2 指令
+// 只有 "#" 紧跟 key=value 的部分视为指令，"# owner=team-a" 这类以空格开头或不含 "=" 的部分视为注释
+func parseDirectives(line string) (string, map[string][]string, error) {
+	directives := make(map[string][]string)
+	idx := strings.Index(line, "#")
+	if idx < 0 {
+		return line, directives, nil
+	}
+
+	for _, part := range strings.Split(line[idx+1:], "#") {
+		fields := strings.Fields(part)
+		if len(fields) == 0 || !strings.HasPrefix(part, fields[0]) {
+			continue
+		}
+		key, value, ok := strings.Cut(fields[0], "=")
+		if !ok {
+			continue
+		}
+		if key != archDirective && key != tagDirective {
+			return "", nil, fmt.Errorf("未知指令 #%s", key)
+		}
+		for _, v := range strings.Split(value, ",") {
+			if v = strings.TrimSpace(v); v != "" {
+				directives[key] = append(directives[key], v)
+			}
+		}
+	}
+	return strings.TrimSpace(line[:idx]), directives, nil
+}
+
+// explicitTag 返回镜像地址中显式写出的 tag (name.ParseReference 在未写 tag 时会补全 latest，需要区分)
+// 对于 repo:tag@sha256:... 返回其中的 tag
+func explicitTag(ref string) (string, bool) {
+	if i := strings.Index(ref, "@"); i >= 0 {
+		ref = ref[:i]
+	}
+	if !strings.Contains(ref[strings.LastIndex(ref, "/")+1:], ":") {
+		return "", false
+	}
+	tag, err := name.NewTag(ref)
+	if err != nil {
+		return "", false
+	}
+	return tag.TagStr(), true
+}
+
+func parseImageSpecs(specs []ImageSpec) ([]ImageEntry, error) {
+	results := make([]ImageEntry, 0, len(specs))
+
+	for i, spec := range specs {
+		// fieldErr 生成带条目序号、行号与字段名的错误信息
+		fieldErr := func(field string, format string, a ...interface{}) error {
+			line := spec.line
+			if l, ok := spec.fieldLines[field]; ok {
+				line = l
+			}
+			return fmt.Errorf("images[%d] 第 %d 行, 字段 %s: %s", i, line, field, fmt.Sprintf(format, a...))
+		}
+
+		source := strings.TrimSpace(spec.Source)
+		if source == "" {
+			return nil, fieldErr("source", "不能为空")
+		}
+
+		ref, err := name.ParseReference(source)
+		if err != nil {
+			return nil, fieldErr("source", "无效的镜像地址 %q: %v", source, err)
+		}
 		repo := ref.Context()
+
+		// 按 digest 固定时 tags (以及 repo:tag@sha256:... 中的 tag) 为推送到目标仓库的 Tag
+		var tags []string
+		digest := ""
+		if d, ok := ref.(name.Digest); ok {
+			digest = d.DigestStr()
+			if tag, ok := explicitTag(source); ok {
+				tags = append(tags, tag)
+			}
+		} else if tag, ok := explicitTag(source); ok {
+			if len(spec.Tags) > 0 {
+				return nil, fieldErr("tags", "source %q 已包含 tag，不能同时指定 tags", source)
+			}
+			tags = []string{tag}
+		}
+		for j, tag := range spec.Tags {
+			tag = strings.TrimSpace(tag)
+			if tag == "" {
+				return nil, fieldErr("tags", "第 %d 个 tag 为空", j+1)
+			}
+			if _, err := name.NewTag(repo.Name() + ":" + tag); err != nil {
+				return nil, fieldErr("tags", "无效的 tag %q: %v", tag, err)
+			}
+			tags = append(tags, tag)
+		}
+
+		targetName := strings.TrimSpace(spec.TargetName)
+		if targetName != "" {
+			// 借助任意 registry 前缀校验仓库路径是否合法
+			if _, err := name.NewRepository("registry.invalid/"+targetName, name.StrictValidation); err != nil {
+				return nil, fieldErr("target_name", "无效的目标镜像名称 %q: %v", targetName, err)
+			}
+		}
+
+		if spec.TagFilter != nil {
+			if len(tags) > 0 || digest != "" {
+				return nil, fieldErr("tag_filter", "仅在未指定 tag 时生效，不能与 tags 或带 tag/digest 的 source 同时使用")
+			}
+			if _, err := tagfilter.New(*spec.TagFilter); err != nil {
+				return nil, fieldErr("tag_filter", "%v", err)
+			}
+		}
+
+		if spec.Verify != nil {
+			if err := spec.Verify.Validate(); err != nil {
+				return nil, fieldErr("verify", "%v", err)
+			}
+		}
+
+		archs := []string{}
+		for j, p := range spec.Platforms {
+			p = strings.TrimSpace(p)
+			if p == "" {
+				return nil, fieldErr("platforms", "第 %d 个平台为空", j+1)
+			}
+			if _, err := platform.Parse(p); err != nil {
+				return nil, fieldErr("platforms", "%v", err)
+			}
+			archs = append(archs, p)
+		}
+		if digest != "" {
+			// 按 digest 固定的镜像原样推送，架构筛选会改变 digest
+			if len(archs) > 0 {
+				return nil, fieldErr("platforms", "按 digest 固定的镜像不能指定 platforms，否则推送的 digest 会改变")
+			}
+		} else if len(archs) == 0 {
+			archs = append(archs, defaultArchitectures...)
+		}
+
 		results = append(results, ImageEntry{
 			Registry:      repo.RegistryStr(),
 			Name:          repo.RepositoryStr(),
-			Tags:          []string{ref.Identifier()},
+			TargetName:    targetName,
+			Tags:          tags,
 			Architectures: archs,
+			Digest:        digest,
+			TagFilter:     spec.TagFilter,
+			Verify:        spec.Verify,
 		})
 	}
 
@@ -0,0 +1,47 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseDirectives(t *testing.T) {
+	tests := []struct {
+		line       string
+		wantRef    string
+		directives map[string][]string
+		wantErr    string
+	}{
+		{"docker.io/library/nginx", "docker.io/library/nginx", map[string][]string{}, ""},
+		{"nginx #arch=amd64,arm64", "nginx", map[string][]string{"arch": {"amd64", "arm64"}}, ""},
+		{"nginx #arch=amd64 #tag=1.25,stable", "nginx", map[string][]string{"arch": {"amd64"}, "tag": {"1.25", "stable"}}, ""},
+		{"nginx@sha256:abc #tag=1.25   # 按 digest 固定", "nginx@sha256:abc", map[string][]string{"tag": {"1.25"}}, ""},
+
+		// 注释中的 key=value 不是指令
+		{"nginx # owner=team-a", "nginx", map[string][]string{}, ""},
+		{"nginx #arch=amd64 # owner=team-a, see #123", "nginx", map[string][]string{"arch": {"amd64"}}, ""},
+		{"nginx # 负责人", "nginx", map[string][]string{}, ""},
+		{"nginx #", "nginx", map[string][]string{}, ""},
+
+		// 拼写错误的指令
+		{"nginx #archs=amd64", "", nil, "未知指令 #archs"},
+		{"nginx # comment #owner=team-a", "", nil, "未知指令 #owner"},
+	}
+	for _, tt := range tests {
+		ref, directives, err := parseDirectives(tt.line)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("parseDirectives(%q) error = %v, want %q", tt.line, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseDirectives(%q) error = %v", tt.line, err)
+			continue
+		}
+		if ref != tt.wantRef || !reflect.DeepEqual(directives, tt.directives) {
+			t.Errorf("parseDirectives(%q) = %q, %v, want %q, %v", tt.line, ref, directives, tt.wantRef, tt.directives)
+		}
+	}
+}
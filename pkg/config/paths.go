@@ -0,0 +1,44 @@
+package config
+
+import (
+	"ikl/pkg/signature"
+	"path/filepath"
+)
+
+// resolvePaths 将仓库配置中的 TLS 证书、签名密钥与签名校验规则的相对路径转换为以 baseDir 为基准的路径
+func (r *RegistryConfig) resolvePaths(baseDir string) {
+	absPaths(baseDir, &r.CAFile, &r.CertFile, &r.KeyFile)
+	if r.Sign != nil {
+		absPaths(baseDir, &r.Sign.Key)
+	}
+	if r.Verify != nil {
+		resolvePolicyPaths(baseDir, r.Verify)
+	}
+}
+
+// resolvePaths 处理所有仓库配置与 images 条目中的相对路径，baseDir 为配置文件所在目录
+func (c *MigrateConfig) resolvePaths(baseDir string) {
+	_ = c.eachRegistry(func(r *RegistryConfig) error {
+		r.resolvePaths(baseDir)
+		return nil
+	})
+	for i := range c.Images {
+		if c.Images[i].Verify != nil {
+			resolvePolicyPaths(baseDir, c.Images[i].Verify)
+		}
+	}
+}
+
+// absPaths 将相对路径转换为以 baseDir 为基准的路径
+func absPaths(baseDir string, paths ...*string) {
+	for _, path := range paths {
+		if *path != "" && !filepath.IsAbs(*path) {
+			*path = filepath.Join(baseDir, *path)
+		}
+	}
+}
+
+// resolvePolicyPaths 处理签名校验规则中的公钥与证书路径
+func resolvePolicyPaths(baseDir string, p *signature.Policy) {
+	absPaths(baseDir, &p.Key, &p.Roots, &p.RekorKey)
+}
@@ -0,0 +1,153 @@
+package config
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// envRefPattern 匹配 ${VAR} 形式的环境变量引用
+var envRefPattern = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// expandEnv 展开字符串中的 ${VAR}，引用未设置的环境变量时报错
+// 仅识别 ${VAR} 形式，密码中单独出现的 $ 保持原样
+func expandEnv(s string) (string, error) {
+	var missing []string
+	out := envRefPattern.ReplaceAllStringFunc(s, func(ref string) string {
+		name := envRefPattern.FindStringSubmatch(ref)[1]
+		v, ok := os.LookupEnv(name)
+		if !ok {
+			missing = append(missing, name)
+		}
+		return v
+	})
+	if len(missing) > 0 {
+		return "", fmt.Errorf("环境变量 %s 未设置", strings.Join(missing, ", "))
+	}
+	return out, nil
+}
+
+// resolveSecrets 展开仓库配置中的 ${VAR} 引用，并读取 password_file / password_env
+// baseDir 为配置文件所在目录，password_file 为相对路径时以此为基准
+func (r *RegistryConfig) resolveSecrets(baseDir string) error {
+	sources := 0
+	for _, v := range []string{r.Password, r.PasswordFile, r.PasswordEnv} {
+		if v != "" {
+			sources++
+		}
+	}
+	if sources > 1 {
+		return fmt.Errorf("password、password_file、password_env 只能配置其中一个")
+	}
+
+	for _, field := range []struct {
+		name  string
+		value *string
+	}{
+		{"username", &r.Username},
+		{"password", &r.Password},
+		{"access_key_id", &r.AccessKeyID},
+		{"access_key_secret", &r.AccessKeySecret},
+	} {
+		v, err := expandEnv(*field.value)
+		if err != nil {
+			return fmt.Errorf("字段 %s: %w", field.name, err)
+		}
+		*field.value = v
+	}
+
+	switch {
+	case r.PasswordEnv != "":
+		v, ok := os.LookupEnv(r.PasswordEnv)
+		if !ok {
+			return fmt.Errorf("字段 password_env: 环境变量 %s 未设置", r.PasswordEnv)
+		}
+		r.Password = v
+	case r.PasswordFile != "":
+		path := r.PasswordFile
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(baseDir, path)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("字段 password_file: 读取 %s 失败: %w", r.PasswordFile, err)
+		}
+		r.Password = strings.TrimRight(string(data), "\r\n")
+	}
+
+	if r.Sign != nil {
+		if err := r.Sign.resolvePassword(); err != nil {
+			return fmt.Errorf("字段 sign: %w", err)
+		}
+	}
+
+	RegisterSecret(r.Password)
+	RegisterSecret(r.AccessKeySecret)
+	if r.Username != "" && r.Password != "" {
+		RegisterSecret(base64.StdEncoding.EncodeToString([]byte(r.Username + ":" + r.Password)))
+	}
+	return nil
+}
+
+// resolvePassword 从 password_env (默认 COSIGN_PASSWORD) 读取私钥密码
+// 使用默认变量且未设置时不报错，私钥未加密时不需要密码
+func (s *SignConfig) resolvePassword() error {
+	name := s.PasswordEnv
+	if name == "" {
+		name = DefaultSignPasswordEnv
+	}
+	v, ok := os.LookupEnv(name)
+	if !ok && s.PasswordEnv != "" {
+		return fmt.Errorf("字段 password_env: 环境变量 %s 未设置", s.PasswordEnv)
+	}
+	s.Password = v
+	RegisterSecret(v)
+	return nil
+}
+
+// resolveSecrets 处理所有源仓库与目标仓库的密钥引用
+func (c *MigrateConfig) resolveSecrets(baseDir string) error {
+	return c.eachRegistry(func(r *RegistryConfig) error {
+		return r.resolveSecrets(baseDir)
+	})
+}
+
+// minSecretLen 过短的字符串替换后会误伤正常输出，不作为密钥脱敏
+const minSecretLen = 4
+
+var (
+	secretsMu sync.RWMutex
+	secrets   []string
+)
+
+// RegisterSecret 登记需要在输出中脱敏的密钥
+func RegisterSecret(s string) {
+	if len(s) < minSecretLen {
+		return
+	}
+	secretsMu.Lock()
+	defer secretsMu.Unlock()
+	for _, existing := range secrets {
+		if existing == s {
+			return
+		}
+	}
+	secrets = append(secrets, s)
+	// 先替换较长的密钥，避免其中包含的较短密钥先被替换
+	sort.Slice(secrets, func(i, j int) bool { return len(secrets[i]) > len(secrets[j]) })
+}
+
+// Redact 将字符串中已登记的密钥替换为 ******
+func Redact(s string) string {
+	secretsMu.RLock()
+	defer secretsMu.RUnlock()
+	for _, secret := range secrets {
+		s = strings.ReplaceAll(s, secret, "******")
+	}
+	return s
+}
@@ -1,7 +1,13 @@
 package config
 
 import (
+	"fmt"
+	"ikl/pkg/signature"
+	"ikl/pkg/tagfilter"
+	"ikl/pkg/tlsutil"
 	"os"
+	"path/filepath"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
@@ -9,10 +15,46 @@ import (
 // RegistryConfig 定义单个仓库的连接信息
 type RegistryConfig struct {
 	Registry string `yaml:"registry"` // 仓库地址
-	Username string `yaml:"username"` // 用户名
-	Password string `yaml:"password"` // 密码
+	Username string `yaml:"username"` // 用户名，支持 ${VAR} 引用环境变量
+	Password string `yaml:"password"` // 密码，支持 ${VAR} 引用环境变量
 	Insecure bool   `yaml:"insecure"` // 是否跳过 TLS 验证
-	Type     string `yaml:"type"`     // [新增] 仓库类型， "harbor"
+	Type     string `yaml:"type"`     // [新增] 仓库类型， "registry" (默认) / "harbor" / "ali"
+
+	PasswordFile string `yaml:"password_file"` // 从文件读取密码，相对路径以配置文件所在目录为基准
+	PasswordEnv  string `yaml:"password_env"`  // 从指定的环境变量读取密码
+
+	// TLS 设置，文件路径为相对路径时以配置文件所在目录为基准
+	CAFile     string `yaml:"ca_file"`     // 额外信任的 CA 证书 (PEM)，用于私有 CA 签发的仓库证书
+	CertFile   string `yaml:"cert_file"`   // 双向 TLS 客户端证书 (PEM)
+	KeyFile    string `yaml:"key_file"`    // 双向 TLS 客户端私钥 (PEM)
+	ServerName string `yaml:"server_name"` // 覆盖校验证书时使用的服务器名称
+
+	Proxy string `yaml:"proxy"` // 该仓库使用的代理，覆盖 --proxy 与环境变量；"direct" 表示直连
+
+	Visibility string `yaml:"visibility"` // 自动管理的项目/命名空间可见性，"public" / "private"，为空时不修改
+
+	Sign *SignConfig `yaml:"sign"` // 推送后为镜像签名（仅 destination_registries 生效）
+
+	RateLimit RateLimitConfig   `yaml:"rate_limit"` // 源仓库限速（仅 source_registries 生效）
+	Verify    *signature.Policy `yaml:"verify"`     // 迁移前校验源镜像的 cosign 签名（仅 source_registries 生效）
+
+	// 以下字段仅用于阿里云容器镜像服务 (type: ali)
+	Namespace       string `yaml:"namespace"`         // 目标命名空间，镜像会被改写为 namespace/<镜像名最后一段>
+	AccessKeyID     string `yaml:"access_key_id"`     // OpenAPI AccessKey，用于自动创建命名空间与仓库
+	AccessKeySecret string `yaml:"access_key_secret"` // OpenAPI AccessKey Secret
+	Region          string `yaml:"region"`            // 地域，默认从仓库地址推断，如 cn-hangzhou
+	Endpoint        string `yaml:"endpoint"`          // OpenAPI 地址，默认 https://cr.<region>.aliyuncs.com
+}
+
+// TLS 返回仓库的 TLS 设置
+func (r RegistryConfig) TLS() tlsutil.Options {
+	return tlsutil.Options{
+		Insecure:   r.Insecure,
+		CAFile:     r.CAFile,
+		CertFile:   r.CertFile,
+		KeyFile:    r.KeyFile,
+		ServerName: r.ServerName,
+	}
 }
 
 // ImageEntry 定义要迁移的镜像条目
@@ -20,8 +62,26 @@ type ImageEntry struct {
 	Registry      string   `yaml:"registry"`      // 源镜像所在的 Registry
 	Name          string   `yaml:"name"`          // 源镜像名称
 	TargetName    string   `yaml:"target_name"`   // 目标镜像名称
-	Tags          []string `yaml:"tags"`          // Tag 列表
+	Tags          []string `yaml:"tags"`          // Tag 列表；指定 Digest 时为推送到目标仓库的 Tag
 	Architectures []string `yaml:"architectures"` // 架构筛选
+	Digest        string   `yaml:"digest"`        // 按 digest 固定的源镜像，如 sha256:...，推送后校验目标 digest 一致
+
+	TagFilter *tagfilter.Selector `yaml:"tag_filter"` // 未指定 Tags 时对全部 Tag 的筛选规则
+	Verify    *signature.Policy   `yaml:"verify"`     // 签名校验规则，为空时使用源仓库的规则
+}
+
+// ImageSpec 对应 images 列表中的结构化条目
+type ImageSpec struct {
+	Source     string   `yaml:"source"`      // 源镜像，如 docker.io/rook/ceph，可带 tag
+	TargetName string   `yaml:"target_name"` // 目标镜像名称（可选，默认与源一致）
+	Tags       []string `yaml:"tags"`        // Tag 列表（可选，为空且 source 未带 tag 时迁移全部 Tag）
+	Platforms  []string `yaml:"platforms"`   // 架构筛选（可选，默认 amd64/arm64）
+
+	TagFilter *tagfilter.Selector `yaml:"tag_filter"` // Tag 筛选规则（可选，仅在迁移全部 Tag 时生效）
+	Verify    *signature.Policy   `yaml:"verify"`     // 签名校验规则（可选，优先于源仓库的 verify）
+
+	line       int            // 条目在配置文件中的行号
+	fieldLines map[string]int // 各字段在配置文件中的行号
 }
 
 // MigrateConfig 对应整个 config.yaml 文件的结构
@@ -29,6 +89,44 @@ type MigrateConfig struct {
 	SourceRegistries map[string]RegistryConfig `yaml:"source_registries"`      // 源仓库集合（可选）
 	DestinationRegs  map[string]RegistryConfig `yaml:"destination_registries"` // 目标仓库集合（必填）
 	ImageList        string                    `yaml:"image_list"`             // 镜像列表（多行）
+	Images           []ImageSpec               `yaml:"images"`                 // 结构化镜像列表（可与 image_list 同时使用）
+	Concurrency      int                       `yaml:"concurrency"`            // 并发迁移任务数（可选，默认 1）
+	Retry            RetryConfig               `yaml:"retry"`                  // 失败重试策略（可选）
+	Artifacts        ArtifactsConfig           `yaml:"artifacts"`              // 签名、证明与 SBOM 的迁移（可选）
+}
+
+// ArtifactsConfig 定义迁移时一并复制的供应链制品，默认均不复制
+type ArtifactsConfig struct {
+	Attestations bool `yaml:"attestations"` // 按架构筛选时保留引用已保留平台的 attestation manifest (BuildKit 生成的 SBOM/provenance)
+	Signatures   bool `yaml:"signatures"`   // 复制 cosign 的 sha256-<digest>.sig/.att/.sbom Tag
+	Referrers    bool `yaml:"referrers"`    // 复制 OCI referrers API 中引用已迁移 digest 的制品
+}
+
+// SignConfig 定义推送到目标仓库后的 cosign 签名
+type SignConfig struct {
+	Key         string `yaml:"key"`          // PEM 私钥 (cosign 加密私钥或未加密的 PKCS#8/EC/RSA)，相对路径以配置文件所在目录为基准
+	PasswordEnv string `yaml:"password_env"` // 加密私钥的密码所在的环境变量，默认 COSIGN_PASSWORD
+	TLog        *bool  `yaml:"tlog"`         // 是否上传到 Rekor 透明日志，目前仅支持显式配置为 false
+
+	Password string `yaml:"-"` // 从环境变量读取的私钥密码
+}
+
+// DefaultSignPasswordEnv 是未配置 password_env 时读取私钥密码的环境变量，与 cosign 一致
+const DefaultSignPasswordEnv = "COSIGN_PASSWORD"
+
+// RateLimitConfig 定义对源仓库的请求限速，未配置时不限速
+type RateLimitConfig struct {
+	RPS       float64       `yaml:"rps"`       // 每秒请求数上限
+	Manifests int           `yaml:"manifests"` // 每个窗口内 Manifest 拉取次数上限
+	Window    time.Duration `yaml:"window"`    // Manifest 拉取次数的统计窗口，如 6h
+}
+
+// RetryConfig 定义拉取/推送遇到临时故障时的重试策略，未配置的字段使用默认值
+type RetryConfig struct {
+	Attempts   int           `yaml:"attempts"`    // 总尝试次数 (含首次)，默认 3，1 表示不重试
+	Backoff    time.Duration `yaml:"backoff"`     // 首次重试前的等待时间，如 2s，之后每次翻倍
+	MaxBackoff time.Duration `yaml:"max_backoff"` // 单次等待时间上限，如 1m
+	Jitter     *float64      `yaml:"jitter"`      // 随机抖动比例 (0~1)，默认 0.2
 }
 
 func LoadConfig(path string) (*MigrateConfig, error) {
@@ -41,5 +139,86 @@ func LoadConfig(path string) (*MigrateConfig, error) {
 	if err := yaml.Unmarshal(data, &cfg); err != nil {
 		return nil, err
 	}
+	baseDir := filepath.Dir(path)
+	if err := cfg.resolveSecrets(baseDir); err != nil {
+		return nil, err
+	}
+	cfg.resolvePaths(baseDir)
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
 	return &cfg, nil
 }
+
+// eachRegistry 依次处理所有源仓库与目标仓库的配置，错误信息带上仓库所在的位置
+func (c *MigrateConfig) eachRegistry(fn func(r *RegistryConfig) error) error {
+	for _, group := range []struct {
+		name string
+		regs map[string]RegistryConfig
+	}{
+		{"source_registries", c.SourceRegistries},
+		{"destination_registries", c.DestinationRegs},
+	} {
+		for key, reg := range group.regs {
+			if err := fn(&reg); err != nil {
+				return fmt.Errorf("%s.%s: %w", group.name, key, err)
+			}
+			group.regs[key] = reg
+		}
+	}
+	return nil
+}
+
+// UnmarshalYAML 解析结构化条目，记录行号并拒绝未知字段
+func (s *ImageSpec) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind != yaml.MappingNode {
+		return fmt.Errorf("images 第 %d 行: 条目必须是包含 source 等字段的对象", node.Line)
+	}
+
+	s.line = node.Line
+	s.fieldLines = make(map[string]int)
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key := node.Content[i]
+		switch key.Value {
+		case "source", "target_name", "tags", "platforms", "verify":
+			s.fieldLines[key.Value] = key.Line
+		case "tag_filter":
+			s.fieldLines[key.Value] = key.Line
+			if err := checkTagFilterFields(node.Content[i+1]); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("images 第 %d 行: 未知字段 %q (支持 source, target_name, tags, platforms, tag_filter, verify)", key.Line, key.Value)
+		}
+	}
+
+	// 使用别名类型避免递归调用 UnmarshalYAML
+	type plain ImageSpec
+	var p plain
+	if err := node.Decode(&p); err != nil {
+		return fmt.Errorf("images 第 %d 行: %w", node.Line, err)
+	}
+	s.Source = p.Source
+	s.TargetName = p.TargetName
+	s.Tags = p.Tags
+	s.Platforms = p.Platforms
+	s.TagFilter = p.TagFilter
+	s.Verify = p.Verify
+	return nil
+}
+
+// checkTagFilterFields 拒绝 tag_filter 中的未知字段，避免拼写错误被静默忽略
+func checkTagFilterFields(node *yaml.Node) error {
+	if node.Kind != yaml.MappingNode {
+		return fmt.Errorf("images 第 %d 行, 字段 tag_filter: 必须是对象", node.Line)
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key := node.Content[i]
+		switch key.Value {
+		case "include", "exclude", "semver", "prerelease", "latest", "newest":
+		default:
+			return fmt.Errorf("images 第 %d 行, 字段 tag_filter: 未知字段 %q (支持 include, exclude, semver, prerelease, latest, newest)", key.Line, key.Value)
+		}
+	}
+	return nil
+}
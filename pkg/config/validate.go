@@ -0,0 +1,30 @@
+package config
+
+import "fmt"
+
+// validate 检查仓库配置中签名与签名校验规则的字段组合
+func (r RegistryConfig) validate() error {
+	if r.Sign != nil {
+		switch {
+		case r.Sign.Key == "":
+			return fmt.Errorf("字段 sign: 需要配置 key")
+		case r.Sign.TLog == nil:
+			return fmt.Errorf("字段 sign: 签名不会上传到 Rekor 透明日志，需显式配置 tlog: false")
+		case *r.Sign.TLog:
+			return fmt.Errorf("字段 sign: 暂不支持上传到 Rekor 透明日志，请配置 tlog: false")
+		}
+	}
+	if r.Verify != nil {
+		if err := r.Verify.Validate(); err != nil {
+			return fmt.Errorf("字段 verify: %w", err)
+		}
+	}
+	return nil
+}
+
+// validate 检查所有源仓库与目标仓库的配置
+func (c *MigrateConfig) validate() error {
+	return c.eachRegistry(func(r *RegistryConfig) error {
+		return r.validate()
+	})
+}
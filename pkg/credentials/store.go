@@ -0,0 +1,197 @@
+package credentials
+
+import (
+	"encoding/base64"
+	"fmt"
+	"ikl/pkg/config"
+	"os"
+	"path/filepath"
+	"strings"
+
+	dockerconfig "github.com/docker/cli/cli/config"
+	"github.com/docker/cli/cli/config/configfile"
+	"github.com/docker/cli/cli/config/types"
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+)
+
+// dockerHubAuthKey 是 Docker Hub 在 config.json 中的固定键名
+const dockerHubAuthKey = "https://index.docker.io/v1/"
+
+// Credential 是从凭据存储中读取的认证信息
+type Credential struct {
+	Username      string
+	Password      string
+	IdentityToken string // 部分仓库 (如 ACR/ECR 的 OAuth 登录) 只返回 identity token
+	Source        string // 凭据来源，如 ~/.docker/config.json 或 docker-credential-osxkeychain
+}
+
+// Empty 判断是否没有任何认证信息
+func (c Credential) Empty() bool {
+	return c.Username == "" && c.Password == "" && c.IdentityToken == ""
+}
+
+// loadFile 读取 docker config.json 格式的凭据文件，文件不存在时返回空配置
+func loadFile(path string) (*configfile.ConfigFile, error) {
+	cf := configfile.New(path)
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return cf, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取凭据文件 %s 失败: %w", path, err)
+	}
+	defer f.Close()
+	if err := cf.LoadFromReader(f); err != nil {
+		return nil, fmt.Errorf("解析凭据文件 %s 失败: %w", path, err)
+	}
+	return cf, nil
+}
+
+// defaultKeychainSource 是 authn.DefaultKeychain 的凭据来源描述
+const defaultKeychainSource = "docker/podman 凭据存储"
+
+// Lookup 查找仓库的认证信息，未找到时返回空的 Credential
+//  1. $REGISTRY_AUTH_FILE (podman/skopeo)
+//  2. authn.DefaultKeychain: docker config.json (含 docker-credential-* 凭据助手)，不存在时为 $XDG_RUNTIME_DIR/containers/auth.json
+//
+// 读取到的密码与 identity token 会登记为需要脱敏的密钥
+func Lookup(registry string) (Credential, error) {
+	cred, err := lookup(registry)
+	if err != nil {
+		return Credential{}, err
+	}
+	config.RegisterSecret(cred.Password)
+	config.RegisterSecret(cred.IdentityToken)
+	if cred.Username != "" && cred.Password != "" {
+		config.RegisterSecret(base64.StdEncoding.EncodeToString([]byte(cred.Username + ":" + cred.Password)))
+	}
+	return cred, nil
+}
+
+func lookup(registry string) (Credential, error) {
+	if path := os.Getenv("REGISTRY_AUTH_FILE"); path != "" {
+		if _, err := os.Stat(path); err == nil {
+			cf, err := loadFile(path)
+			if err != nil {
+				return Credential{}, err
+			}
+			key := authKey(registry)
+			auth, err := cf.GetAuthConfig(key)
+			if err != nil {
+				return Credential{}, fmt.Errorf("从 %s 读取 %s 的凭据失败: %w", storeName(cf, key), registry, err)
+			}
+			cred := Credential{
+				Username:      auth.Username,
+				Password:      auth.Password,
+				IdentityToken: auth.IdentityToken,
+				Source:        storeName(cf, key),
+			}
+			if !cred.Empty() {
+				return cred, nil
+			}
+		}
+	}
+
+	reg, err := name.NewRegistry(strings.TrimSuffix(strings.TrimPrefix(strings.TrimPrefix(registry, "https://"), "http://"), "/"))
+	if err != nil {
+		return Credential{}, fmt.Errorf("解析仓库地址 %s 失败: %w", registry, err)
+	}
+	auth, err := authn.DefaultKeychain.Resolve(reg)
+	if err != nil {
+		return Credential{}, fmt.Errorf("从%s读取 %s 的凭据失败: %w", defaultKeychainSource, registry, err)
+	}
+	cfg, err := auth.Authorization()
+	if err != nil {
+		return Credential{}, fmt.Errorf("从%s读取 %s 的凭据失败: %w", defaultKeychainSource, registry, err)
+	}
+	username, password := cfg.Username, cfg.Password
+	if username == "" && password == "" && cfg.Auth != "" {
+		// config.json 中仅有 auth 字段 (base64 编码的 user:password) 时需自行解码
+		if decoded, err := base64.StdEncoding.DecodeString(cfg.Auth); err == nil {
+			username, password, _ = strings.Cut(string(decoded), ":")
+		}
+	}
+	return Credential{
+		Username:      username,
+		Password:      password,
+		IdentityToken: cfg.IdentityToken,
+		Source:        defaultKeychainSource,
+	}, nil
+}
+
+// writableFile 返回 login/logout 写入的凭据文件：设置了 $REGISTRY_AUTH_FILE 时使用该文件，否则使用 docker config.json
+func writableFile() string {
+	if f := os.Getenv("REGISTRY_AUTH_FILE"); f != "" {
+		return f
+	}
+	return filepath.Join(dockerconfig.Dir(), dockerconfig.ConfigFileName)
+}
+
+// Store 保存仓库的认证信息，返回实际写入的位置
+// config.json 中配置了 credsStore/credHelpers 时写入对应的凭据助手
+func Store(registry, username, password string) (string, error) {
+	cf, err := loadFile(writableFile())
+	if err != nil {
+		return "", err
+	}
+	key := authKey(registry)
+	if err := os.MkdirAll(filepath.Dir(cf.Filename), 0o700); err != nil {
+		return "", fmt.Errorf("创建目录 %s 失败: %w", filepath.Dir(cf.Filename), err)
+	}
+	err = cf.GetCredentialsStore(key).Store(types.AuthConfig{
+		Username:      username,
+		Password:      password,
+		ServerAddress: key,
+	})
+	if err != nil {
+		return "", fmt.Errorf("保存凭据到 %s 失败: %w", storeName(cf, key), err)
+	}
+	return storeName(cf, key), nil
+}
+
+// Erase 删除仓库的认证信息，返回删除的位置
+func Erase(registry string) (string, error) {
+	cf, err := loadFile(writableFile())
+	if err != nil {
+		return "", err
+	}
+	key := authKey(registry)
+	if _, ok := cf.AuthConfigs[key]; !ok && !usesHelper(cf, key) {
+		return "", fmt.Errorf("%s 中没有 %s 的凭据", cf.Filename, registry)
+	}
+	if err := cf.GetCredentialsStore(key).Erase(key); err != nil {
+		return "", fmt.Errorf("从 %s 删除凭据失败: %w", storeName(cf, key), err)
+	}
+	return storeName(cf, key), nil
+}
+
+// authKey 返回仓库在凭据存储中的键名，Docker Hub 的各种别名统一为 https://index.docker.io/v1/
+func authKey(registry string) string {
+	registry = strings.TrimPrefix(strings.TrimPrefix(registry, "https://"), "http://")
+	registry = strings.TrimSuffix(registry, "/")
+	switch registry {
+	case "", "docker.io", "index.docker.io", "registry-1.docker.io":
+		return dockerHubAuthKey
+	}
+	return registry
+}
+
+// usesHelper 判断仓库的凭据是否由 docker-credential-* 凭据助手管理
+func usesHelper(cf *configfile.ConfigFile, key string) bool {
+	if _, ok := cf.CredentialHelpers[key]; ok {
+		return true
+	}
+	return cf.CredentialsStore != ""
+}
+
+// storeName 返回凭据的存储位置，用于提示信息
+func storeName(cf *configfile.ConfigFile, key string) string {
+	if helper, ok := cf.CredentialHelpers[key]; ok {
+		return "docker-credential-" + helper
+	}
+	if cf.CredentialsStore != "" {
+		return "docker-credential-" + cf.CredentialsStore
+	}
+	return cf.Filename
+}
@@ -0,0 +1,143 @@
+package credentials
+
+import (
+	"encoding/base64"
+	"ikl/pkg/config"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// isolate 将凭据相关的环境变量指向临时目录，返回 docker 配置目录
+func isolate(t *testing.T) string {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	dockerDir := filepath.Join(home, ".docker")
+	t.Setenv("DOCKER_CONFIG", dockerDir)
+	t.Setenv("REGISTRY_AUTH_FILE", "")
+	t.Setenv("XDG_RUNTIME_DIR", t.TempDir())
+	return dockerDir
+}
+
+func writeAuthFile(t *testing.T, path, registry, username, password string) {
+	t.Helper()
+	auth := base64.StdEncoding.EncodeToString([]byte(username + ":" + password))
+	data := `{"auths":{"` + registry + `":{"auth":"` + auth + `"}}}`
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLookup(t *testing.T) {
+	tests := []struct {
+		name     string
+		setup    func(t *testing.T, dockerDir string)
+		registry string
+		want     Credential
+	}{
+		{
+			name: "docker config.json",
+			setup: func(t *testing.T, dockerDir string) {
+				writeAuthFile(t, filepath.Join(dockerDir, "config.json"), "ykl.io:40443", "admin", "docker-secret")
+			},
+			registry: "ykl.io:40443",
+			want:     Credential{Username: "admin", Password: "docker-secret", Source: defaultKeychainSource},
+		},
+		{
+			name: "Docker Hub 别名",
+			setup: func(t *testing.T, dockerDir string) {
+				writeAuthFile(t, filepath.Join(dockerDir, "config.json"), "https://index.docker.io/v1/", "hub", "hub-secret")
+			},
+			registry: "docker.io",
+			want:     Credential{Username: "hub", Password: "hub-secret", Source: defaultKeychainSource},
+		},
+		{
+			name: "REGISTRY_AUTH_FILE 优先",
+			setup: func(t *testing.T, dockerDir string) {
+				writeAuthFile(t, filepath.Join(dockerDir, "config.json"), "ykl.io:40443", "admin", "docker-secret")
+				path := filepath.Join(t.TempDir(), "auth.json")
+				writeAuthFile(t, path, "ykl.io:40443", "podman", "podman-secret")
+				t.Setenv("REGISTRY_AUTH_FILE", path)
+			},
+			registry: "ykl.io:40443",
+			want:     Credential{Username: "podman", Password: "podman-secret"},
+		},
+		{
+			name: "podman auth.json",
+			setup: func(t *testing.T, dockerDir string) {
+				writeAuthFile(t, filepath.Join(os.Getenv("XDG_RUNTIME_DIR"), "containers", "auth.json"), "quay.io", "robot", "quay-secret")
+			},
+			registry: "quay.io",
+			want:     Credential{Username: "robot", Password: "quay-secret", Source: defaultKeychainSource},
+		},
+		{
+			name:     "未登录",
+			setup:    func(t *testing.T, dockerDir string) {},
+			registry: "ykl.io:40443",
+			want:     Credential{Source: defaultKeychainSource},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dockerDir := isolate(t)
+			tt.setup(t, dockerDir)
+
+			got, err := Lookup(tt.registry)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.want.Source == "" {
+				tt.want.Source = os.Getenv("REGISTRY_AUTH_FILE")
+			}
+			if got != tt.want {
+				t.Errorf("Lookup(%q) = %+v, want %+v", tt.registry, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLookupRegistersSecrets(t *testing.T) {
+	dockerDir := isolate(t)
+	writeAuthFile(t, filepath.Join(dockerDir, "config.json"), "ykl.io:40443", "admin", "s3cr3t-from-store")
+
+	if _, err := Lookup("ykl.io:40443"); err != nil {
+		t.Fatal(err)
+	}
+	if out := config.Redact("login failed: s3cr3t-from-store"); strings.Contains(out, "s3cr3t-from-store") {
+		t.Errorf("凭据存储中的密码未脱敏: %s", out)
+	}
+}
+
+func TestStoreAndErase(t *testing.T) {
+	dockerDir := isolate(t)
+
+	location, err := Store("ykl.io:40443", "admin", "stored-secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(dockerDir, "config.json"); location != want {
+		t.Errorf("location = %s, want %s", location, want)
+	}
+	cred, err := Lookup("ykl.io:40443")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cred.Username != "admin" || cred.Password != "stored-secret" {
+		t.Errorf("Lookup 后 = %+v", cred)
+	}
+
+	if _, err := Erase("ykl.io:40443"); err != nil {
+		t.Fatal(err)
+	}
+	if cred, _ := Lookup("ykl.io:40443"); !cred.Empty() {
+		t.Errorf("Erase 后仍能读取凭据: %+v", cred)
+	}
+	if _, err := Erase("ykl.io:40443"); err == nil {
+		t.Error("删除不存在的凭据应返回错误")
+	}
+}
@@ -2,9 +2,10 @@ package harbor
 
 import (
 	"bytes"
-	"crypto/tls"
 	"encoding/json"
 	"fmt"
+	"ikl/pkg/httptransport"
+	"ikl/pkg/tlsutil"
 	"io"
 	"net/http"
 	"net/url"
@@ -21,7 +22,7 @@ type Client struct {
 
 // NewClient 创建 Harbor API 客户端
 // address: 例如 "jusuan.io:8080"
-func NewClient(address, username, password string, insecure bool, proxyURL string, noProxy string) (*Client, error) {
+func NewClient(address, username, password string, tlsOpts tlsutil.Options, proxyURL string, noProxy string) (*Client, error) {
 	// 默认使用 HTTPS，除非用户在地址中明确指定了 http://
 	baseURL := address
 	if !strings.HasPrefix(baseURL, "http://") && !strings.HasPrefix(baseURL, "https://") {
@@ -29,34 +30,13 @@ func NewClient(address, username, password string, insecure bool, proxyURL strin
 	}
 	baseURL = strings.TrimSuffix(baseURL, "/")
 
-	transport := &http.Transport{
-		TLSClientConfig: &tls.Config{InsecureSkipVerify: insecure},
-	}
-
-	// 处理代理
-	if proxyURL != "" {
-		pURL, err := url.Parse(proxyURL)
-		if err != nil {
-			return nil, fmt.Errorf("无效代理地址: %w", err)
-		}
-
-		noProxyList := strings.Split(noProxy, ",")
-		for i := range noProxyList {
-			noProxyList[i] = strings.TrimSpace(noProxyList[i])
-		}
-
-		transport.Proxy = func(req *http.Request) (*url.URL, error) {
-			host := req.URL.Hostname()
-			for _, np := range noProxyList {
-				if np == "" {
-					continue
-				}
-				if host == np || strings.HasSuffix(host, "."+np) {
-					return nil, nil // 直连
-				}
-			}
-			return pURL, nil
-		}
+	transport, err := httptransport.New(httptransport.Options{
+		TLS:     tlsOpts,
+		Proxy:   proxyURL,
+		NoProxy: noProxy,
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	return &Client{
@@ -181,3 +161,35 @@ func (c *Client) createProject(project string) error {
 	body, _ := io.ReadAll(resp.Body)
 	return fmt.Errorf("创建失败 (%d): %s", resp.StatusCode, string(body))
 }
+
+// SetProjectPublic 设置项目的可见性 (公开/私有)
+func (c *Client) SetProjectPublic(project string, public bool) error {
+	apiURL := fmt.Sprintf("%s/api/v2.0/projects/%s", c.BaseURL, url.PathEscape(project))
+
+	payload := map[string]interface{}{
+		"metadata": map[string]string{
+			"public": fmt.Sprintf("%t", public),
+		},
+	}
+	jsonBody, _ := json.Marshal(payload)
+
+	req, err := http.NewRequest("PUT", apiURL, bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(c.Username, c.Password)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("设置项目 %s 可见性失败: %w", project, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+
+	body, _ := io.ReadAll(resp.Body)
+	return fmt.Errorf("设置项目 %s 可见性失败 (%d): %s", project, resp.StatusCode, string(body))
+}
@@ -0,0 +1,118 @@
+package httptransport
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"golang.org/x/net/http/httpproxy"
+)
+
+// Direct 作为代理地址时表示不使用任何代理 (包括环境变量中的代理)
+const Direct = "direct"
+
+// ProxyFunc 返回 http.Transport.Proxy 使用的代理选择函数
+//   - proxy 为 Direct 时始终直连
+//   - proxy 非空时所有请求使用该代理，noProxy 为空时使用环境变量 NO_PROXY
+//   - proxy 为空时按环境变量选择：https 请求使用 HTTPS_PROXY，http 请求使用 HTTP_PROXY，均未设置时使用 ALL_PROXY
+//   - noProxy 的语法与 Go 标准库一致 (golang.org/x/net/http/httpproxy)：*、CIDR、IP、域名 (匹配自身及子域名)、
+//     以 . 开头的域名、带端口的条目；localhost 与回环地址始终直连
+//
+// 代理地址支持 http://、https://、socks5://、socks5h://，省略协议时视为 http://
+func ProxyFunc(proxy, noProxy string) (func(*http.Request) (*url.URL, error), error) {
+	if strings.EqualFold(proxy, Direct) {
+		return nil, nil
+	}
+
+	if noProxy == "" {
+		noProxy = getEnv("NO_PROXY")
+	}
+
+	var httpProxy, httpsProxy *url.URL
+	if proxy != "" {
+		u, err := parseProxyURL(proxy)
+		if err != nil {
+			return nil, err
+		}
+		httpProxy, httpsProxy = u, u
+	} else {
+		all := getEnv("ALL_PROXY")
+		var err error
+		if httpProxy, err = parseProxyURL(firstNonEmpty(getEnv("HTTP_PROXY"), all)); err != nil {
+			return nil, fmt.Errorf("环境变量 HTTP_PROXY: %w", err)
+		}
+		if httpsProxy, err = parseProxyURL(firstNonEmpty(getEnv("HTTPS_PROXY"), all)); err != nil {
+			return nil, fmt.Errorf("环境变量 HTTPS_PROXY: %w", err)
+		}
+	}
+	if httpProxy == nil && httpsProxy == nil {
+		return nil, nil
+	}
+
+	// httpproxy 只用于判断请求是否绕过代理 (NO_PROXY 规则，以及始终直连的 localhost/回环地址)
+	// 实际使用的代理地址由 parseProxyURL 解析，以支持 httpproxy 不识别的 socks5h
+	placeholder := func(u *url.URL) string {
+		if u == nil {
+			return ""
+		}
+		return "http://proxy.invalid"
+	}
+	bypass := (&httpproxy.Config{
+		HTTPProxy:  placeholder(httpProxy),
+		HTTPSProxy: placeholder(httpsProxy),
+		NoProxy:    noProxy,
+	}).ProxyFunc()
+
+	return func(req *http.Request) (*url.URL, error) {
+		if u, err := bypass(req.URL); err != nil || u == nil {
+			return nil, err
+		}
+		if req.URL.Scheme == "https" {
+			return httpsProxy, nil
+		}
+		return httpProxy, nil
+	}, nil
+}
+
+// parseProxyURL 解析代理地址，空字符串返回 nil
+func parseProxyURL(s string) (*url.URL, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil, nil
+	}
+	if !strings.Contains(s, "://") {
+		s = "http://" + s
+	}
+	u, err := url.Parse(s)
+	if err != nil {
+		return nil, fmt.Errorf("无效的代理地址 %q: %w", s, err)
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5", "socks5h":
+	default:
+		return nil, fmt.Errorf("不支持的代理协议 %q (支持 http, https, socks5, socks5h)", u.Scheme)
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("无效的代理地址 %q: 缺少主机", s)
+	}
+	return u, nil
+}
+
+// getEnv 读取环境变量，大写形式优先，其次为小写形式
+func getEnv(key string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return os.Getenv(strings.ToLower(key))
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
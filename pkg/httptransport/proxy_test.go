@@ -0,0 +1,189 @@
+package httptransport
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// clearProxyEnv 清除代理相关的环境变量，避免测试受运行环境影响
+func clearProxyEnv(t *testing.T) {
+	t.Helper()
+	for _, key := range []string{"HTTP_PROXY", "HTTPS_PROXY", "ALL_PROXY", "NO_PROXY"} {
+		t.Setenv(key, "")
+		t.Setenv(strings.ToLower(key), "")
+	}
+}
+
+func TestProxyFuncNoProxy(t *testing.T) {
+	tests := []struct {
+		noProxy string
+		url     string
+		direct  bool
+	}{
+		{noProxy: "*", url: "https://registry-1.docker.io/v2/", direct: true},
+		{noProxy: "ykl.io", url: "https://ykl.io:40443/v2/", direct: true},
+		{noProxy: "ykl.io", url: "https://harbor.ykl.io/v2/", direct: true},
+		{noProxy: "ykl.io", url: "https://notykl.io/v2/", direct: false},
+		{noProxy: ".ykl.io", url: "https://harbor.ykl.io/v2/", direct: true},
+		{noProxy: ".ykl.io", url: "https://ykl.io/v2/", direct: false},
+		{noProxy: "*.ykl.io", url: "https://harbor.ykl.io/v2/", direct: true},
+		{noProxy: "ykl.io:40443", url: "https://ykl.io:40443/v2/", direct: true},
+		{noProxy: "ykl.io:40443", url: "https://ykl.io/v2/", direct: false},
+		{noProxy: "10.0.0.0/8", url: "http://10.1.2.3:5000/v2/", direct: true},
+		{noProxy: "10.0.0.0/8", url: "http://192.168.1.10:5000/v2/", direct: false},
+		{noProxy: "192.168.1.10", url: "http://192.168.1.10:5000/v2/", direct: true},
+		{noProxy: "docker.io, ykl.io", url: "https://ykl.io/v2/", direct: true},
+		{noProxy: "", url: "http://localhost:5000/v2/", direct: true},
+		{noProxy: "", url: "http://127.0.0.1:5000/v2/", direct: true},
+		{noProxy: "", url: "https://quay.io/v2/", direct: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.noProxy+" "+tt.url, func(t *testing.T) {
+			clearProxyEnv(t)
+			fn, err := ProxyFunc("http://127.0.0.1:7890", tt.noProxy)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req := httptest.NewRequest(http.MethodGet, tt.url, nil)
+			u, err := fn(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if direct := u == nil; direct != tt.direct {
+				t.Errorf("direct = %v, want %v (proxy %v)", direct, tt.direct, u)
+			}
+		})
+	}
+}
+
+func TestProxyFuncEnv(t *testing.T) {
+	tests := []struct {
+		name  string
+		env   map[string]string
+		proxy string
+		url   string
+		want  string
+	}{
+		{name: "HTTPS_PROXY", env: map[string]string{"HTTPS_PROXY": "http://https-proxy:3128"}, url: "https://quay.io/v2/", want: "http://https-proxy:3128"},
+		{name: "HTTP_PROXY 仅用于 http", env: map[string]string{"HTTP_PROXY": "http-proxy:3128"}, url: "https://quay.io/v2/", want: ""},
+		{name: "小写环境变量", env: map[string]string{"http_proxy": "http://lower:3128"}, url: "http://quay.io/v2/", want: "http://lower:3128"},
+		{name: "ALL_PROXY", env: map[string]string{"ALL_PROXY": "socks5h://127.0.0.1:1080"}, url: "https://quay.io/v2/", want: "socks5h://127.0.0.1:1080"},
+		{name: "NO_PROXY 环境变量", env: map[string]string{"HTTPS_PROXY": "http://p:3128", "NO_PROXY": "quay.io"}, url: "https://quay.io/v2/", want: ""},
+		{name: "--proxy 优先于环境变量", env: map[string]string{"HTTPS_PROXY": "http://env:3128"}, proxy: "socks5://127.0.0.1:1080", url: "https://quay.io/v2/", want: "socks5://127.0.0.1:1080"},
+		{name: "direct 忽略环境变量", env: map[string]string{"HTTPS_PROXY": "http://env:3128"}, proxy: "direct", url: "https://quay.io/v2/", want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearProxyEnv(t)
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			fn, err := ProxyFunc(tt.proxy, "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := ""
+			if fn != nil {
+				u, err := fn(httptest.NewRequest(http.MethodGet, tt.url, nil))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if u != nil {
+					got = u.String()
+				}
+			}
+			if got != tt.want {
+				t.Errorf("proxy = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProxyFuncInvalid(t *testing.T) {
+	clearProxyEnv(t)
+	for _, proxy := range []string{"ftp://proxy:21", "http://", "http://[::1"} {
+		if _, err := ProxyFunc(proxy, ""); err == nil {
+			t.Errorf("ProxyFunc(%q) 应返回错误", proxy)
+		}
+	}
+}
+
+// proxyStandIn 是记录请求的 HTTP 代理替身，直接以 200 响应所有经过代理的请求
+type proxyStandIn struct {
+	mu    sync.Mutex
+	hosts []string
+}
+
+func (p *proxyStandIn) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	p.mu.Lock()
+	p.hosts = append(p.hosts, r.URL.Host)
+	p.mu.Unlock()
+	w.WriteHeader(http.StatusOK)
+}
+
+func (p *proxyStandIn) requests() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]string(nil), p.hosts...)
+}
+
+func TestTransportUsesProxy(t *testing.T) {
+	envProxy := &proxyStandIn{}
+	envSrv := httptest.NewServer(envProxy)
+	defer envSrv.Close()
+	flagProxy := &proxyStandIn{}
+	flagSrv := httptest.NewServer(flagProxy)
+	defer flagSrv.Close()
+
+	clearProxyEnv(t)
+	t.Setenv("HTTP_PROXY", envSrv.URL)
+
+	get := func(opts Options) error {
+		tr, err := New(opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := (&http.Client{Transport: tr}).Get("http://registry.invalid/v2/")
+		if err == nil {
+			resp.Body.Close()
+		}
+		return err
+	}
+
+	// 未指定代理时使用环境变量
+	if err := get(Options{}); err != nil {
+		t.Fatal(err)
+	}
+	// --proxy 或仓库单独配置的 proxy 优先于环境变量
+	if err := get(Options{Proxy: flagSrv.URL}); err != nil {
+		t.Fatal(err)
+	}
+	// NO_PROXY 命中时直连，direct 时忽略所有代理；registry.invalid 无法解析，因此请求失败
+	if err := get(Options{Proxy: flagSrv.URL, NoProxy: "registry.invalid"}); err == nil {
+		t.Error("命中 NO_PROXY 时不应经过代理")
+	}
+	if err := get(Options{Proxy: Direct}); err == nil {
+		t.Error("direct 时不应经过代理")
+	}
+
+	if got := envProxy.requests(); len(got) != 1 || got[0] != "registry.invalid" {
+		t.Errorf("环境变量代理收到的请求 = %v, want [registry.invalid]", got)
+	}
+	if got := flagProxy.requests(); len(got) != 1 || got[0] != "registry.invalid" {
+		t.Errorf("--proxy 代理收到的请求 = %v, want [registry.invalid]", got)
+	}
+}
+
+func TestParseProxyURL(t *testing.T) {
+	u, err := parseProxyURL("127.0.0.1:7890")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (&url.URL{Scheme: "http", Host: "127.0.0.1:7890"}); u.String() != want.String() {
+		t.Errorf("parseProxyURL = %s, want %s", u, want)
+	}
+}
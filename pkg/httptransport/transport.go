@@ -0,0 +1,43 @@
+package httptransport
+
+import (
+	"ikl/pkg/tlsutil"
+	"net"
+	"net/http"
+	"time"
+)
+
+// Options 定义创建 HTTP Transport 所需的 TLS 与代理设置
+type Options struct {
+	TLS     tlsutil.Options
+	Proxy   string // 代理地址，为空时使用环境变量 HTTP_PROXY/HTTPS_PROXY/ALL_PROXY，Direct 表示直连
+	NoProxy string // 不走代理的主机列表，为空时使用环境变量 NO_PROXY
+}
+
+// New 创建 Registry、Harbor API、ACR OpenAPI 共用的 HTTP Transport
+// 连接池与超时参数与 go-containerregistry 的默认 Transport 一致
+func New(opts Options) (*http.Transport, error) {
+	tlsConfig, err := opts.TLS.Config()
+	if err != nil {
+		return nil, err
+	}
+	proxy, err := ProxyFunc(opts.Proxy, opts.NoProxy)
+	if err != nil {
+		return nil, err
+	}
+
+	return &http.Transport{
+		Proxy: proxy,
+		DialContext: (&net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		TLSClientConfig:       tlsConfig,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          100,
+		MaxIdleConnsPerHost:   50,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+	}, nil
+}
@@ -0,0 +1,145 @@
+package journal
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// FileName 是默认的状态日志文件名，位于配置文件同级目录
+const FileName = ".ikl-state.json"
+
+// 迁移条目的状态
+const (
+	StatusRunning = "running"
+	StatusSuccess = "success"
+	StatusFailed  = "failed"
+)
+
+// Entry 记录单个 源镜像 -> 目标镜像 的迁移状态
+type Entry struct {
+	Source            string    `json:"source"`            // 源镜像引用
+	Destination       string    `json:"destination"`       // 目标镜像引用
+	SourceDigest      string    `json:"sourceDigest"`      // 解析得到的源镜像 digest
+	DestinationDigest string    `json:"destinationDigest"` // 推送到目标仓库的 digest (架构筛选后可能与源不同)
+	Status            string    `json:"status"`            // running / success / failed
+	Error             string    `json:"error,omitempty"`   // 失败原因
+	UpdatedAt         time.Time `json:"updatedAt"`
+}
+
+// Journal 是持久化的迁移状态日志，每次更新都会立即落盘
+type Journal struct {
+	path    string
+	mu      sync.Mutex
+	entries map[string]*Entry
+}
+
+// DefaultPath 返回配置文件同级目录下的状态日志路径
+func DefaultPath(configPath string) string {
+	return filepath.Join(filepath.Dir(configPath), FileName)
+}
+
+// New 创建一个空的状态日志，首次 Record 时才会写入文件；path 为空时仅保存在内存中
+func New(path string) *Journal {
+	return &Journal{
+		path:    path,
+		entries: make(map[string]*Entry),
+	}
+}
+
+// Load 读取已有的状态日志，文件不存在时返回空日志
+func Load(path string) (*Journal, error) {
+	j := New(path)
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return j, nil
+		}
+		return nil, fmt.Errorf("读取状态日志失败: %w", err)
+	}
+
+	var entries []*Entry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("解析状态日志 %s 失败: %w", path, err)
+	}
+	for _, e := range entries {
+		j.entries[key(e.Source, e.Destination)] = e
+	}
+	return j, nil
+}
+
+// Path 返回状态日志文件路径
+func (j *Journal) Path() string {
+	return j.path
+}
+
+// Get 查询指定 源 -> 目标 的迁移记录
+func (j *Journal) Get(source, destination string) (Entry, bool) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	e, ok := j.entries[key(source, destination)]
+	if !ok {
+		return Entry{}, false
+	}
+	return *e, true
+}
+
+// Counts 返回记录总数与其中失败或未完成的条目数
+func (j *Journal) Counts() (total, unfinished int) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	for _, e := range j.entries {
+		if e.Status != StatusSuccess {
+			unfinished++
+		}
+	}
+	return len(j.entries), unfinished
+}
+
+// Record 更新一条迁移记录并立即写回文件
+func (j *Journal) Record(e Entry) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	e.UpdatedAt = time.Now()
+	j.entries[key(e.Source, e.Destination)] = &e
+	if j.path == "" {
+		return nil
+	}
+	return j.save()
+}
+
+// save 以 "写临时文件 + 重命名" 的方式落盘，避免进程中断时留下半截文件，调用方需持有锁
+func (j *Journal) save() error {
+	entries := make([]*Entry, 0, len(j.entries))
+	for _, e := range j.entries {
+		entries = append(entries, e)
+	}
+	sort.Slice(entries, func(a, b int) bool {
+		return key(entries[a].Source, entries[a].Destination) < key(entries[b].Source, entries[b].Destination)
+	})
+
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp := j.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("写入状态日志失败: %w", err)
+	}
+	if err := os.Rename(tmp, j.path); err != nil {
+		return fmt.Errorf("写入状态日志失败: %w", err)
+	}
+	return nil
+}
+
+func key(source, destination string) string {
+	return source + " -> " + destination
+}
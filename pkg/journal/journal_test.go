@@ -0,0 +1,103 @@
+package journal
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadMissing(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+	j, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total, _ := j.Counts(); total != 0 {
+		t.Errorf("Counts = %d, want 0", total)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("Load 不应创建文件: %v", err)
+	}
+}
+
+func TestRecordAndLoad(t *testing.T) {
+	dir := t.TempDir()
+	path := DefaultPath(filepath.Join(dir, "config.yaml"))
+	if path != filepath.Join(dir, FileName) {
+		t.Fatalf("DefaultPath = %s", path)
+	}
+
+	j := New(path)
+	for _, e := range []Entry{
+		{Source: "docker.io/library/redis:7", Destination: "ykl.io/library/redis:7", Status: StatusRunning},
+		{Source: "docker.io/library/nginx:1", Destination: "ykl.io/library/nginx:1", Status: StatusFailed, Error: "timeout"},
+		// 同一 源 -> 目标 的记录被更新
+		{Source: "docker.io/library/redis:7", Destination: "ykl.io/library/redis:7", Status: StatusSuccess, SourceDigest: "sha256:1", DestinationDigest: "sha256:2"},
+	} {
+		if err := j.Record(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 原子写入后不留下临时文件
+	if _, err := os.Stat(path + ".tmp"); !os.IsNotExist(err) {
+		t.Errorf("残留临时文件: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{`"sourceDigest": "sha256:1"`, `"destinationDigest": "sha256:2"`, `"updatedAt"`} {
+		if !strings.Contains(string(data), key) {
+			t.Errorf("状态日志缺少 %s:\n%s", key, data)
+		}
+	}
+	if strings.Index(string(data), "nginx") > strings.Index(string(data), "redis") {
+		t.Errorf("条目应按 源 -> 目标 排序:\n%s", data)
+	}
+
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total, unfinished := loaded.Counts(); total != 2 || unfinished != 1 {
+		t.Errorf("Counts = %d, %d, want 2, 1", total, unfinished)
+	}
+	e, ok := loaded.Get("docker.io/library/redis:7", "ykl.io/library/redis:7")
+	if !ok || e.Status != StatusSuccess || e.DestinationDigest != "sha256:2" || e.UpdatedAt.IsZero() {
+		t.Errorf("Get = %+v, %v", e, ok)
+	}
+	if _, ok := loaded.Get("docker.io/library/redis:7", "ykl.io/library/redis:6"); ok {
+		t.Errorf("不存在的记录 Get 返回 ok")
+	}
+}
+
+func TestRecordInMemory(t *testing.T) {
+	j := New("")
+	if err := j.Record(Entry{Source: "a", Destination: "b", Status: StatusSuccess}); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := j.Get("a", "b"); !ok {
+		t.Errorf("内存中的记录丢失")
+	}
+}
+
+func TestLoadInvalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+	if err := os.WriteFile(path, []byte("{not json"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(path); err == nil || !strings.Contains(err.Error(), "解析状态日志") {
+		t.Errorf("err = %v", err)
+	}
+}
+
+func TestRecordWriteError(t *testing.T) {
+	// 所在目录不存在时写入失败
+	path := t.TempDir()
+	j := New(filepath.Join(path, "missing", FileName))
+	if err := j.Record(Entry{Source: "a", Destination: "b"}); err == nil || !strings.Contains(err.Error(), "写入状态日志失败") {
+		t.Errorf("err = %v", err)
+	}
+}
@@ -0,0 +1,161 @@
+package platform
+
+import (
+	"fmt"
+	"strings"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+)
+
+// Wildcard 匹配任意取值
+const Wildcard = "*"
+
+// unknown 是 BuildKit attestation manifest 的操作系统与架构
+const unknown = "unknown"
+
+// Spec 是解析后的平台筛选条件，字段为空或为 Wildcard 时匹配任意取值
+type Spec struct {
+	OS           string
+	OSVersion    string // Windows 的 os.version，按 "." 分段前缀匹配，如 10.0.17763 匹配 10.0.17763.5458
+	Architecture string
+	Variant      string
+}
+
+// Parse 解析平台筛选条件，支持:
+//   - arch，如 amd64、arm64，匹配任意操作系统
+//   - os/arch[/variant]，如 linux/arm/v7、windows/amd64
+//   - os(os.version)/arch，如 windows(10.0.17763)/amd64
+//   - 任意部分使用 * 通配，如 linux/*、*/arm64
+//
+// 常见别名会被规范化：x86_64 -> amd64，aarch64 -> arm64，armhf -> arm/v7，armel -> arm/v6
+func Parse(s string) (Spec, error) {
+	raw := s
+	s = strings.ToLower(strings.TrimSpace(s))
+	if s == "" {
+		return Spec{}, fmt.Errorf("平台不能为空")
+	}
+
+	parts := strings.Split(s, "/")
+	var spec Spec
+	switch len(parts) {
+	case 1:
+		spec.Architecture = parts[0]
+	case 2, 3:
+		os := parts[0]
+		if open := strings.Index(os, "("); open >= 0 {
+			if !strings.HasSuffix(os, ")") {
+				return Spec{}, fmt.Errorf("无效的平台 %q: os.version 需写作 os(version)", raw)
+			}
+			spec.OSVersion = os[open+1 : len(os)-1]
+			os = os[:open]
+		}
+		spec.OS = os
+		spec.Architecture = parts[1]
+		if len(parts) == 3 {
+			spec.Variant = parts[2]
+		}
+	default:
+		return Spec{}, fmt.Errorf("无效的平台 %q: 格式应为 arch 或 os/arch[/variant]", raw)
+	}
+
+	if spec.Architecture == "" || (len(parts) > 1 && spec.OS == "") {
+		return Spec{}, fmt.Errorf("无效的平台 %q: 格式应为 arch 或 os/arch[/variant]", raw)
+	}
+	if len(parts) == 3 && spec.Variant == "" {
+		return Spec{}, fmt.Errorf("无效的平台 %q: variant 不能为空", raw)
+	}
+	if spec.OS == Wildcard && spec.OSVersion != "" {
+		return Spec{}, fmt.Errorf("无效的平台 %q: 通配的操作系统不能指定 os.version", raw)
+	}
+
+	spec.Architecture, spec.Variant = normalizeArch(spec.Architecture, spec.Variant)
+	return spec, nil
+}
+
+// ParseAll 解析多个平台筛选条件
+func ParseAll(values []string) ([]Spec, error) {
+	specs := make([]Spec, 0, len(values))
+	for _, v := range values {
+		spec, err := Parse(v)
+		if err != nil {
+			return nil, err
+		}
+		specs = append(specs, spec)
+	}
+	return specs, nil
+}
+
+// Match 判断平台是否符合筛选条件，各部分均为精确匹配 (arm 不会匹配 arm64)
+// 未指定 variant 时匹配该架构的所有 variant；通配不匹配 unknown 的操作系统与架构，
+// 即 * 与 linux/* 不会选中 BuildKit 的 attestation manifest，这类条目按 artifacts.attestations 单独保留
+func (s Spec) Match(p *v1.Platform) bool {
+	if p == nil {
+		return false
+	}
+	arch, variant := normalizeArch(strings.ToLower(p.Architecture), strings.ToLower(p.Variant))
+
+	if !matchPart(s.OS, strings.ToLower(p.OS)) || !matchPart(s.Architecture, arch) {
+		return false
+	}
+	if s.Variant != "" && s.Variant != Wildcard && s.Variant != variant {
+		return false
+	}
+	if s.OSVersion != "" && p.OSVersion != s.OSVersion && !strings.HasPrefix(p.OSVersion, s.OSVersion+".") {
+		return false
+	}
+	return true
+}
+
+// MatchAny 判断平台是否符合任意一个筛选条件
+func MatchAny(specs []Spec, p *v1.Platform) bool {
+	for _, s := range specs {
+		if s.Match(p) {
+			return true
+		}
+	}
+	return false
+}
+
+// Format 将平台转换为 os/arch[/variant] 形式的名称，Windows 镜像附带 os.version，如 windows(10.0.17763.5458)/amd64
+// 架构为空或 unknown (如 BuildKit 的 attestation manifest) 时返回空字符串
+func Format(p *v1.Platform) string {
+	if p == nil || p.Architecture == "" || p.Architecture == unknown {
+		return ""
+	}
+	os := p.OS
+	if p.OSVersion != "" {
+		os += "(" + p.OSVersion + ")"
+	}
+	name := os + "/" + p.Architecture
+	if p.Variant != "" {
+		name += "/" + p.Variant
+	}
+	return name
+}
+
+func matchPart(want, got string) bool {
+	if want == "" || want == Wildcard {
+		return got != unknown
+	}
+	return want == got
+}
+
+// normalizeArch 规范化架构别名，arm64 的 v8 variant 视为默认值
+func normalizeArch(arch, variant string) (string, string) {
+	switch arch {
+	case "x86_64", "x86-64":
+		arch = "amd64"
+	case "aarch64":
+		arch = "arm64"
+	case "armhf":
+		arch, variant = "arm", "v7"
+	case "armel":
+		arch, variant = "arm", "v6"
+	case "i386", "i686":
+		arch = "386"
+	}
+	if arch == "arm64" && variant == "v8" {
+		variant = ""
+	}
+	return arch, variant
+}
@@ -0,0 +1,96 @@
+package platform
+
+import (
+	"strings"
+	"testing"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+)
+
+func TestMatch(t *testing.T) {
+	linuxAmd64 := &v1.Platform{OS: "linux", Architecture: "amd64"}
+	linuxArm64 := &v1.Platform{OS: "linux", Architecture: "arm64"}
+	linuxArm64v8 := &v1.Platform{OS: "linux", Architecture: "arm64", Variant: "v8"}
+	linuxArmV7 := &v1.Platform{OS: "linux", Architecture: "arm", Variant: "v7"}
+	linuxArmV6 := &v1.Platform{OS: "linux", Architecture: "arm", Variant: "v6"}
+	windows := &v1.Platform{OS: "windows", Architecture: "amd64", OSVersion: "10.0.17763.5458"}
+	attestation := &v1.Platform{OS: "unknown", Architecture: "unknown"}
+
+	tests := []struct {
+		spec string
+		p    *v1.Platform
+		want bool
+	}{
+		{"amd64", linuxAmd64, true},
+		{"x86_64", linuxAmd64, true},
+		{"amd64", windows, true},
+		{"arm", linuxArm64, false},
+		{"arm64", linuxArmV7, false},
+		{"linux/arm", linuxArmV7, true},
+		{"linux/arm/v7", linuxArmV7, true},
+		{"linux/arm/v7", linuxArmV6, false},
+		{"linux/arm/v6", linuxArmV6, true},
+		{"armhf", linuxArmV7, true},
+		{"armel", linuxArmV6, true},
+		{"linux/arm64", linuxArm64v8, true},
+		{"linux/arm64/v8", linuxArm64, true},
+		{"aarch64", linuxArm64v8, true},
+		{"linux/*", linuxArmV7, true},
+		{"linux/*", windows, false},
+		{"*/arm64", linuxArm64, true},
+		{"*", linuxAmd64, true},
+		{"*/*", windows, true},
+		{"linux/arm/*", linuxArmV6, true},
+		{"windows(10.0.17763)/amd64", windows, true},
+		{"windows(10.0.17763.5458)/amd64", windows, true},
+		{"windows(10.0.1776)/amd64", windows, false},
+		{"windows(10.0.20348)/amd64", windows, false},
+		{"windows/amd64", windows, true},
+		{"*", attestation, false},
+		{"*/*", attestation, false},
+		{"linux/*", attestation, false},
+		{"unknown/unknown", attestation, true},
+		{"*", nil, false},
+	}
+	for _, tt := range tests {
+		spec, err := Parse(tt.spec)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", tt.spec, err)
+		}
+		if got := spec.Match(tt.p); got != tt.want {
+			t.Errorf("%q.Match(%s) = %v, want %v", tt.spec, Format(tt.p), got, tt.want)
+		}
+	}
+}
+
+func TestParseAllErrors(t *testing.T) {
+	tests := []struct {
+		values []string
+		want   string
+	}{
+		{[]string{"amd64", ""}, "平台不能为空"},
+		{[]string{"linux/arm/v7/extra"}, "格式应为 arch 或 os/arch[/variant]"},
+		{[]string{"linux/"}, "格式应为 arch 或 os/arch[/variant]"},
+		{[]string{"/amd64"}, "格式应为 arch 或 os/arch[/variant]"},
+		{[]string{"linux/arm/"}, "variant 不能为空"},
+		{[]string{"windows(10.0/amd64"}, "os.version 需写作 os(version)"},
+		{[]string{"*(10.0.17763)/amd64"}, "通配的操作系统不能指定 os.version"},
+	}
+	for _, tt := range tests {
+		_, err := ParseAll(tt.values)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("ParseAll(%q) err = %v, want 包含 %q", tt.values, err, tt.want)
+		}
+	}
+
+	specs, err := ParseAll([]string{"Linux/ARM64", "windows(10.0.17763)/amd64"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Spec{{OS: "linux", Architecture: "arm64"}, {OS: "windows", OSVersion: "10.0.17763", Architecture: "amd64"}}
+	for i := range want {
+		if specs[i] != want[i] {
+			t.Errorf("specs[%d] = %+v, want %+v", i, specs[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package provider
+
+import (
+	"fmt"
+	"ikl/pkg/acr"
+	"strings"
+)
+
+func init() {
+	Register("ali", newACR)
+	Register("acr", newACR)
+}
+
+// acrProvider 将镜像改写到配置的命名空间下，并通过 ACR OpenAPI 自动创建命名空间与镜像仓库
+type acrProvider struct {
+	namespace  string
+	client     *acr.Client // 未配置 AccessKey 时为空，仅改写镜像名称
+	namespaces onceSet
+	repos      onceSet
+	visibility onceSet
+}
+
+func newACR(opts Options) (Provider, error) {
+	cfg := opts.Config
+	if cfg.Namespace == "" {
+		return nil, fmt.Errorf("阿里云 ACR 目标仓库 %s 必须配置 namespace", opts.Registry)
+	}
+
+	p := &acrProvider{namespace: cfg.Namespace}
+	if cfg.AccessKeyID == "" || cfg.AccessKeySecret == "" {
+		fmt.Printf("⚠️  ACR 目标仓库 %s 未配置 access_key_id/access_key_secret，跳过命名空间与仓库的自动创建\n", opts.Registry)
+		return p, nil
+	}
+
+	region := cfg.Region
+	if region == "" {
+		region = acr.RegionFromRegistry(opts.Registry)
+	}
+	client, err := acr.NewClient(
+		cfg.Endpoint,
+		region,
+		cfg.AccessKeyID,
+		cfg.AccessKeySecret,
+		cfg.TLS(),
+		opts.Proxy,
+		opts.NoProxy,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("初始化 ACR 客户端失败: %w", err)
+	}
+	p.client = client
+	return p, nil
+}
+
+func (p *acrProvider) RepositoryName(name string) string {
+	return acr.RepositoryName(p.namespace, name)
+}
+
+func (p *acrProvider) EnsureNamespace(repo string) error {
+	if p.client == nil {
+		return nil
+	}
+	name := strings.TrimPrefix(repo, p.namespace+"/")
+
+	if err := p.namespaces.Do(p.namespace, func() error {
+		if err := p.client.EnsureNamespace(p.namespace); err != nil {
+			return fmt.Errorf("无法自动创建/检查 ACR 命名空间 '%s': %w", p.namespace, err)
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	return p.repos.Do(repo, func() error {
+		if err := p.client.EnsureRepository(p.namespace, name); err != nil {
+			return fmt.Errorf("无法自动创建/检查 ACR 镜像仓库 '%s': %w", repo, err)
+		}
+		return nil
+	})
+}
+
+// SetVisibility ACR 的可见性在镜像仓库级别设置
+func (p *acrProvider) SetVisibility(repo string, public bool) error {
+	if p.client == nil {
+		return nil
+	}
+	name := strings.TrimPrefix(repo, p.namespace+"/")
+	return p.visibility.Do(repo, func() error {
+		return p.client.SetRepositoryPublic(p.namespace, name, public)
+	})
+}
+
+func (p *acrProvider) AfterPush(repo, tag, digest string) error {
+	return nil
+}
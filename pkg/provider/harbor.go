@@ -0,0 +1,73 @@
+package provider
+
+import (
+	"fmt"
+	"ikl/pkg/harbor"
+	"strings"
+)
+
+func init() {
+	Register("harbor", newHarbor)
+}
+
+// harborProvider 在推送前自动创建 Harbor 项目
+type harborProvider struct {
+	client     *harbor.Client
+	ensured    onceSet
+	visibility onceSet
+}
+
+func newHarbor(opts Options) (Provider, error) {
+	client, err := harbor.NewClient(
+		opts.Registry,
+		opts.Config.Username,
+		opts.Config.Password,
+		opts.Config.TLS(),
+		opts.Proxy,
+		opts.NoProxy,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("初始化 Harbor 客户端失败: %w", err)
+	}
+	return &harborProvider{client: client}, nil
+}
+
+// project 提取项目名称 (例如 "rook/ceph" -> "rook")
+func (p *harborProvider) project(repo string) string {
+	parts := strings.Split(repo, "/")
+	if len(parts) < 2 {
+		return ""
+	}
+	return parts[0]
+}
+
+func (p *harborProvider) RepositoryName(name string) string {
+	return name
+}
+
+func (p *harborProvider) EnsureNamespace(repo string) error {
+	project := p.project(repo)
+	if project == "" {
+		return nil
+	}
+	return p.ensured.Do(project, func() error {
+		if err := p.client.EnsureProject(project); err != nil {
+			return fmt.Errorf("无法自动创建/检查 Harbor 项目 '%s': %w", project, err)
+		}
+		return nil
+	})
+}
+
+func (p *harborProvider) SetVisibility(repo string, public bool) error {
+	project := p.project(repo)
+	if project == "" {
+		return nil
+	}
+	return p.visibility.Do(project, func() error {
+		return p.client.SetProjectPublic(project, public)
+	})
+}
+
+func (p *harborProvider) AfterPush(repo, tag, digest string) error {
+	return nil
+}
@@ -0,0 +1,147 @@
+package provider
+
+import (
+	"fmt"
+	"ikl/pkg/config"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// DefaultType 是未配置 type 时使用的普通仓库类型
+const DefaultType = "registry"
+
+// Provider 封装目标仓库类型相关的行为，由配置中的 type 字段选择具体实现
+type Provider interface {
+	// RepositoryName 返回镜像在目标仓库中的实际名称，例如 ACR 会改写到命名空间下
+	RepositoryName(name string) string
+	// EnsureNamespace 确保镜像所属的项目/命名空间 (以及仓库) 存在，需支持并发调用且幂等
+	EnsureNamespace(repo string) error
+	// SetVisibility 设置镜像所属项目/命名空间的可见性
+	SetVisibility(repo string, public bool) error
+	// AfterPush 在镜像成功推送后调用，可用于触发扫描、同步元数据等
+	AfterPush(repo, tag, digest string) error
+}
+
+// Options 是创建 Provider 所需的参数
+type Options struct {
+	Registry string                // 目标仓库地址，如 ykl.io:40443
+	Config   config.RegistryConfig // 目标仓库配置
+	Proxy    string
+	NoProxy  string
+}
+
+// Factory 根据配置创建 Provider
+type Factory func(opts Options) (Provider, error)
+
+var (
+	factoriesMu sync.RWMutex
+	factories   = make(map[string]Factory)
+)
+
+// Register 注册一种目标仓库类型，通常在实现文件的 init 中调用
+func Register(typ string, factory Factory) {
+	factoriesMu.Lock()
+	defer factoriesMu.Unlock()
+	factories[strings.ToLower(typ)] = factory
+}
+
+// Types 返回已注册的仓库类型
+func Types() []string {
+	factoriesMu.RLock()
+	defer factoriesMu.RUnlock()
+	types := make([]string, 0, len(factories))
+	for t := range factories {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// New 根据 opts.Config.Type 创建对应的 Provider，type 为空时使用普通仓库
+func New(opts Options) (Provider, error) {
+	typ := strings.ToLower(opts.Config.Type)
+	if typ == "" {
+		typ = DefaultType
+	}
+
+	factoriesMu.RLock()
+	factory, ok := factories[typ]
+	factoriesMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("不支持的仓库类型 %q (支持: %s)", opts.Config.Type, strings.Join(Types(), ", "))
+	}
+	return factory(opts)
+}
+
+// CheckConflicts 检查多个镜像名称经 RepositoryName 改写后是否落到目标仓库中的同一个仓库
+// 例如 ACR 只保留名称最后一段，a/nginx 与 b/nginx 都会改写为 ns/nginx，后推送的镜像会覆盖前者的 Tag
+func CheckConflicts(p Provider, names []string) error {
+	sources := make(map[string][]string)
+	for _, name := range names {
+		repo := p.RepositoryName(name)
+		if !contains(sources[repo], name) {
+			sources[repo] = append(sources[repo], name)
+		}
+	}
+
+	var conflicts []string
+	for repo, names := range sources {
+		if len(names) > 1 {
+			sort.Strings(names)
+			conflicts = append(conflicts, fmt.Sprintf("%s -> %s", strings.Join(names, ", "), repo))
+		}
+	}
+	if len(conflicts) == 0 {
+		return nil
+	}
+	sort.Strings(conflicts)
+	return fmt.Errorf("多个镜像会推送到同一个仓库，请通过 target_name 区分: %s", strings.Join(conflicts, "; "))
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// onceSet 记录已成功处理过的键，保证同一项目/命名空间只调用一次 API
+type onceSet struct {
+	mu   sync.Mutex
+	keys map[string]*onceKey
+}
+
+// onceKey 是单个键的状态，其锁仅在同一个键的并发调用之间互斥
+type onceKey struct {
+	mu   sync.Mutex
+	done bool
+}
+
+// Do 对每个 key 执行 fn 直到成功一次；同一 key 的并发调用会等待进行中的调用，不同 key 互不阻塞
+// fn 失败时不记录该 key，后续任务会重新尝试
+func (o *onceSet) Do(key string, fn func() error) error {
+	o.mu.Lock()
+	if o.keys == nil {
+		o.keys = make(map[string]*onceKey)
+	}
+	k, ok := o.keys[key]
+	if !ok {
+		k = &onceKey{}
+		o.keys[key] = k
+	}
+	o.mu.Unlock()
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if k.done {
+		return nil
+	}
+	if err := fn(); err != nil {
+		return err
+	}
+	k.done = true
+	return nil
+}
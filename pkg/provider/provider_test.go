@@ -0,0 +1,100 @@
+package provider
+
+import (
+	"errors"
+	"ikl/pkg/config"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCheckConflicts(t *testing.T) {
+	acrProvider, err := New(Options{Registry: "registry.cn-hangzhou.aliyuncs.com", Config: config.RegistryConfig{Type: "ali", Namespace: "mirror"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	plain, err := New(Options{Registry: "ykl.io:40443"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	names := []string{"a/nginx", "b/nginx", "a/nginx", "sig-storage/csi-attacher"}
+	err = CheckConflicts(acrProvider, names)
+	if err == nil || !strings.Contains(err.Error(), "a/nginx, b/nginx -> mirror/nginx") {
+		t.Errorf("err = %v, want a/nginx 与 b/nginx 冲突", err)
+	}
+	if err := CheckConflicts(acrProvider, []string{"a/nginx", "a/nginx", "b/redis"}); err != nil {
+		t.Errorf("同名镜像的多个 Tag 不应视为冲突: %v", err)
+	}
+	if err := CheckConflicts(plain, names); err != nil {
+		t.Errorf("普通仓库保留完整路径，不应冲突: %v", err)
+	}
+}
+
+func TestOnceSetRetriesAfterFailure(t *testing.T) {
+	var o onceSet
+	calls := 0
+	fail := errors.New("harbor unavailable")
+
+	if err := o.Do("library", func() error { calls++; return fail }); err != fail {
+		t.Fatalf("err = %v, want %v", err, fail)
+	}
+	if err := o.Do("library", func() error { calls++; return nil }); err != nil {
+		t.Fatal(err)
+	}
+	if err := o.Do("library", func() error { calls++; return nil }); err != nil {
+		t.Fatal(err)
+	}
+	if calls != 2 {
+		t.Errorf("calls = %d, want 2 (失败后重试，成功后不再调用)", calls)
+	}
+}
+
+func TestOnceSetConcurrentKeys(t *testing.T) {
+	var o onceSet
+	var calls int32
+	release := make(chan struct{})
+	started := make(chan struct{})
+
+	// 一个 key 的调用阻塞时，其他 key 不受影响
+	go func() {
+		_ = o.Do("slow", func() error {
+			close(started)
+			<-release
+			return nil
+		})
+	}()
+	<-started
+
+	done := make(chan struct{})
+	go func() {
+		_ = o.Do("fast", func() error { return nil })
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("不同 key 的调用被阻塞")
+	}
+	close(release)
+
+	// 同一 key 的并发调用只执行一次
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_ = o.Do("shared", func() error {
+				atomic.AddInt32(&calls, 1)
+				time.Sleep(10 * time.Millisecond)
+				return nil
+			})
+		}()
+	}
+	wg.Wait()
+	if calls != 1 {
+		t.Errorf("calls = %d, want 1", calls)
+	}
+}
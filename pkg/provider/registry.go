@@ -0,0 +1,26 @@
+package provider
+
+func init() {
+	Register(DefaultType, func(opts Options) (Provider, error) {
+		return plainRegistry{}, nil
+	})
+}
+
+// plainRegistry 是普通的 Docker Registry，不需要额外的项目管理
+type plainRegistry struct{}
+
+func (plainRegistry) RepositoryName(name string) string {
+	return name
+}
+
+func (plainRegistry) EnsureNamespace(repo string) error {
+	return nil
+}
+
+func (plainRegistry) SetVisibility(repo string, public bool) error {
+	return nil
+}
+
+func (plainRegistry) AfterPush(repo, tag, digest string) error {
+	return nil
+}
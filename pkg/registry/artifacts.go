@@ -0,0 +1,301 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"ikl/pkg/signature"
+	"net/http"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
+)
+
+// BuildKit 在 Image Index 中以 unknown/unknown 平台记录 attestation manifest，
+// 并通过注解指向其描述的平台镜像
+const (
+	annotationReferenceType   = "vnd.docker.reference.type"
+	annotationReferenceDigest = "vnd.docker.reference.digest"
+	referenceTypeAttestation  = "attestation-manifest"
+)
+
+// cosignSuffixes 是 cosign 以 sha256-<hex>.<suffix> 形式保存签名、证明与 SBOM 的 Tag 后缀
+var cosignSuffixes = []string{"sig", "att", "sbom"}
+
+// maxReferrerDepth 限制 referrers 的递归层数 (如 SBOM 的签名)
+const maxReferrerDepth = 3
+
+// attestationsFor 返回 manifests 中引用了 kept 内平台镜像的 attestation manifest
+func attestationsFor(manifests, kept []v1.Descriptor) []v1.Descriptor {
+	keptDigests := make(map[string]bool, len(kept))
+	for _, d := range kept {
+		keptDigests[d.Digest.String()] = true
+	}
+
+	var attestations []v1.Descriptor
+	for _, m := range manifests {
+		if m.Annotations[annotationReferenceType] != referenceTypeAttestation {
+			continue
+		}
+		if keptDigests[m.Annotations[annotationReferenceDigest]] {
+			attestations = append(attestations, m)
+		}
+	}
+	return attestations
+}
+
+// CopyArtifacts 复制 Source 中各 Manifest 关联的签名、证明与 SBOM，返回复制的制品数量
+//   - signatures: cosign 的 sha256-<hex>.sig/.att/.sbom Tag
+//   - referrers: OCI referrers (/v2/<name>/referrers/<digest>)，源仓库不支持该 API 时读取 sha256-<hex> 索引 Tag
+//
+// 目标仓库中已存在相同 digest 的制品会跳过，已存在的 cosign Tag 只追加缺少的签名层；架构筛选改变了 Index digest 时，源 Index 自身的制品无法沿用，通过 logf 提示
+func CopyArtifacts(ctx context.Context, src *Source, srcClient, dstClient *Client, srcRepo, dstRepo string, signatures, referrers bool, logf func(format string, a ...interface{})) (int, error) {
+	srcRepoRef, err := name.NewRepository(srcClient.URL+"/"+srcRepo, getNameOptions(srcClient.Insecure)...)
+	if err != nil {
+		return 0, fmt.Errorf("解析源镜像地址失败: %w", err)
+	}
+	dstRepoRef, err := name.NewRepository(dstClient.URL+"/"+dstRepo, getNameOptions(dstClient.Insecure)...)
+	if err != nil {
+		return 0, fmt.Errorf("解析目标镜像地址失败: %w", err)
+	}
+
+	c := &artifactCopier{
+		src:     srcRepoRef,
+		dst:     dstRepoRef,
+		srcOpts: append(srcClient.GetOptions(), remote.WithContext(ctx)),
+		dstOpts: append(dstClient.GetOptions(), remote.WithContext(ctx)),
+		visited: make(map[string]bool),
+		logf:    logf,
+	}
+
+	if src.Digest != src.SourceDigest && c.hasArtifacts(src.SourceDigest, signatures, referrers) {
+		logf("   💡 架构筛选改变了镜像 digest，源镜像 %s 自身的签名/证明无法沿用，仅复制各平台镜像的制品\n", ShortDigest(src.SourceDigest))
+	}
+
+	for _, subject := range src.Subjects {
+		if signatures {
+			if err := c.copySignatures(subject); err != nil {
+				return c.copied, err
+			}
+		}
+		if referrers {
+			if err := c.copyReferrers(subject, 0); err != nil {
+				return c.copied, err
+			}
+		}
+	}
+	return c.copied, nil
+}
+
+type artifactCopier struct {
+	src, dst name.Repository
+	srcOpts  []remote.Option
+	dstOpts  []remote.Option
+	visited  map[string]bool // 已处理的 referrer digest
+	copied   int
+	logf     func(format string, a ...interface{})
+}
+
+// hasArtifacts 判断源仓库中是否存在关联到 subject 的 cosign Tag 或 referrers
+func (c *artifactCopier) hasArtifacts(subject string, signatures, referrers bool) bool {
+	if signatures {
+		prefix := strings.Replace(subject, ":", "-", 1)
+		for _, suffix := range cosignSuffixes {
+			if _, err := remote.Head(c.src.Tag(prefix+"."+suffix), c.srcOpts...); err == nil {
+				return true
+			}
+		}
+	}
+	if referrers {
+		if idx, err := remote.Referrers(c.src.Digest(subject), c.srcOpts...); err == nil {
+			if m, err := idx.IndexManifest(); err == nil && len(m.Manifests) > 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// copySignatures 复制 subject 对应的 cosign Tag
+// 目标 Tag 已存在且内容不同 (如目标仓库自己的签名) 时，将源 Tag 中缺少的层追加到目标 Tag，不覆盖已有的签名
+func (c *artifactCopier) copySignatures(subject string) error {
+	prefix := strings.Replace(subject, ":", "-", 1)
+	for _, suffix := range cosignSuffixes {
+		tag := prefix + "." + suffix
+		desc, err := remote.Get(c.src.Tag(tag), c.srcOpts...)
+		if isNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("获取 %s 失败: %w", tag, err)
+		}
+
+		dstDesc, err := remote.Get(c.dst.Tag(tag), c.dstOpts...)
+		switch {
+		case isNotFound(err):
+			if err := c.write(c.dst.Tag(tag), desc); err != nil {
+				return fmt.Errorf("复制 %s 失败: %w", tag, err)
+			}
+			c.copied++
+		case err != nil:
+			return fmt.Errorf("获取目标仓库的 %s 失败: %w", tag, err)
+		case dstDesc.Digest == desc.Digest:
+		default:
+			merged, err := c.mergeLayers(c.dst.Tag(tag), desc, dstDesc)
+			if err != nil {
+				return fmt.Errorf("合并 %s 失败: %w", tag, err)
+			}
+			if merged {
+				c.copied++
+			}
+		}
+	}
+	return nil
+}
+
+// mergeLayers 将源 cosign 镜像中目标镜像没有的层 (按 digest 与签名注解判断) 追加到目标镜像并推送
+// 没有需要追加的层时不写入，返回 false
+func (c *artifactCopier) mergeLayers(ref name.Tag, srcDesc, dstDesc *remote.Descriptor) (bool, error) {
+	if !srcDesc.MediaType.IsImage() || !dstDesc.MediaType.IsImage() {
+		c.logf("   ⚠️  目标仓库已存在不同的 %s 且不是镜像 Manifest，无法合并，已跳过\n", ref.TagStr())
+		return false, nil
+	}
+	srcImg, err := srcDesc.Image()
+	if err != nil {
+		return false, err
+	}
+	dstImg, err := dstDesc.Image()
+	if err != nil {
+		return false, err
+	}
+	srcManifest, err := srcImg.Manifest()
+	if err != nil {
+		return false, err
+	}
+	dstManifest, err := dstImg.Manifest()
+	if err != nil {
+		return false, err
+	}
+
+	// 同一载荷可能由不同私钥签名，层 digest 相同而签名注解不同，因此两者一起作为层的标识
+	layerKey := func(d v1.Descriptor) string {
+		return d.Digest.String() + "\n" + d.Annotations[signature.AnnotationSignature]
+	}
+	existing := make(map[string]bool, len(dstManifest.Layers))
+	for _, l := range dstManifest.Layers {
+		existing[layerKey(l)] = true
+	}
+
+	var adds []mutate.Addendum
+	for _, l := range srcManifest.Layers {
+		if existing[layerKey(l)] {
+			continue
+		}
+		layer, err := srcImg.LayerByDigest(l.Digest)
+		if err != nil {
+			return false, err
+		}
+		adds = append(adds, mutate.Addendum{Layer: layer, MediaType: l.MediaType, Annotations: l.Annotations})
+		existing[layerKey(l)] = true
+	}
+	if len(adds) == 0 {
+		return false, nil
+	}
+
+	merged, err := mutate.Append(dstImg, adds...)
+	if err != nil {
+		return false, err
+	}
+	return true, remote.Write(ref, merged, c.dstOpts...)
+}
+
+// copyReferrers 按 digest 复制引用 subject 的制品，并递归复制制品自身的 referrers
+func (c *artifactCopier) copyReferrers(subject string, depth int) error {
+	if depth >= maxReferrerDepth {
+		return nil
+	}
+	idx, err := remote.Referrers(c.src.Digest(subject), c.srcOpts...)
+	if err != nil {
+		return fmt.Errorf("获取 %s 的 referrers 失败: %w", ShortDigest(subject), err)
+	}
+	manifest, err := idx.IndexManifest()
+	if err != nil {
+		return err
+	}
+
+	for _, ref := range manifest.Manifests {
+		digest := ref.Digest.String()
+		if c.visited[digest] {
+			continue
+		}
+		c.visited[digest] = true
+
+		_, err := remote.Head(c.dst.Digest(digest), c.dstOpts...)
+		switch {
+		case err == nil:
+		case isNotFound(err):
+			desc, err := remote.Get(c.src.Digest(digest), c.srcOpts...)
+			if err != nil {
+				return fmt.Errorf("获取 referrer %s 失败: %w", ShortDigest(digest), err)
+			}
+			if err := c.write(c.dst.Digest(digest), desc); err != nil {
+				return fmt.Errorf("复制 referrer %s 失败: %w", ShortDigest(digest), err)
+			}
+			c.copied++
+		default:
+			return fmt.Errorf("检查目标仓库的 referrer %s 失败: %w", ShortDigest(digest), err)
+		}
+
+		if err := c.copyReferrers(digest, depth+1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// write 将源 Manifest 原样推送到目标地址；带有 subject 的 Manifest 在目标仓库不支持
+// referrers API 时由 go-containerregistry 更新 sha256-<hex> 索引 Tag
+func (c *artifactCopier) write(ref name.Reference, desc *remote.Descriptor) error {
+	if desc.MediaType.IsIndex() {
+		idx, err := desc.ImageIndex()
+		if err != nil {
+			return err
+		}
+		return remote.WriteIndex(ref, idx, c.dstOpts...)
+	}
+	img, err := desc.Image()
+	if err != nil {
+		return err
+	}
+	return remote.Write(ref, img, c.dstOpts...)
+}
+
+// isNotFound 判断错误是否为 Manifest 不存在
+func isNotFound(err error) bool {
+	var terr *transport.Error
+	if !errors.As(err, &terr) {
+		return false
+	}
+	if terr.StatusCode == http.StatusNotFound {
+		return true
+	}
+	for _, e := range terr.Errors {
+		if e.Code == transport.ManifestUnknownErrorCode || e.Code == transport.NameUnknownErrorCode {
+			return true
+		}
+	}
+	return false
+}
+
+// ShortDigest 截断 digest 便于日志展示，如 sha256:0123456789ab
+func ShortDigest(digest string) string {
+	const prefix = "sha256:"
+	if strings.HasPrefix(digest, prefix) && len(digest) > len(prefix)+12 {
+		return digest[:len(prefix)+12]
+	}
+	return digest
+}
@@ -0,0 +1,219 @@
+package registry
+
+import (
+	"context"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"ikl/pkg/signature"
+	"ikl/pkg/tlsutil"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	ggcrregistry "github.com/google/go-containerregistry/pkg/registry"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/partial"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/static"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+)
+
+// writeSignatureTag 推送一个包含指定签名层的 cosign .sig 镜像，sigs 为载荷 -> 签名注解
+func writeSignatureTag(t *testing.T, c *Client, repo, digest string, sigs ...[2]string) {
+	t.Helper()
+	img := mutate.ConfigMediaType(mutate.MediaType(empty.Image, types.OCIManifestSchema1), types.OCIConfigJSON)
+	for _, s := range sigs {
+		var err error
+		img, err = mutate.Append(img, mutate.Addendum{
+			Layer:       static.NewLayer([]byte(s[0]), signature.SimpleSigningMediaType),
+			Annotations: map[string]string{signature.AnnotationSignature: s[1]},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	ref, err := name.ParseReference(RefString(c.URL, repo, signature.SignatureTag(digest)), getNameOptions(c.Insecure)...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := remote.Write(ref, img, c.GetOptions()...); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// signatureLayers 返回 cosign .sig 镜像中各层的签名注解
+func signatureLayers(t *testing.T, c *Client, repo, digest string) []string {
+	t.Helper()
+	ref, err := name.ParseReference(RefString(c.URL, repo, signature.SignatureTag(digest)), getNameOptions(c.Insecure)...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, err := remote.Image(ref, c.GetOptions()...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := img.Manifest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sigs []string
+	for _, l := range m.Layers {
+		sigs = append(sigs, l.Annotations[signature.AnnotationSignature])
+	}
+	return sigs
+}
+
+func TestCopySignaturesMergesExisting(t *testing.T) {
+	ctx := context.Background()
+	srcClient := newTestClient(t)
+	dstClient := newTestClient(t)
+	writeTestImage(t, srcClient, "library/app", "v1")
+
+	src, err := ResolveSource(ctx, srcClient, "library/app", "v1", nil, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := PushImage(ctx, src, dstClient, "mirror/app", "v1", nil, false); err != nil {
+		t.Fatal(err)
+	}
+
+	payload := `{"critical":{"image":{"docker-manifest-digest":"` + src.Digest + `"}}}`
+	writeSignatureTag(t, srcClient, "library/app", src.Digest, [2]string{payload, "c3JjLXNpZw=="})
+	// 目标仓库已有自己的签名 (如 ikl 推送后签名)，载荷相同但签名不同
+	writeSignatureTag(t, dstClient, "mirror/app", src.Digest, [2]string{payload, "ZHN0LXNpZw=="})
+
+	logf := func(format string, a ...interface{}) {}
+	copied, err := CopyArtifacts(ctx, src, srcClient, dstClient, "library/app", "mirror/app", true, false, logf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if copied != 1 {
+		t.Errorf("copied = %d, want 1", copied)
+	}
+	if got := strings.Join(signatureLayers(t, dstClient, "mirror/app", src.Digest), ","); got != "ZHN0LXNpZw==,c3JjLXNpZw==" {
+		t.Errorf("目标签名层 = %s, 应保留已有签名并追加源签名", got)
+	}
+
+	// 再次复制时没有缺少的层，不写入也不计数
+	copied, err = CopyArtifacts(ctx, src, srcClient, dstClient, "library/app", "mirror/app", true, false, logf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if copied != 0 {
+		t.Errorf("copied = %d, want 0", copied)
+	}
+}
+
+func TestCopySignaturesNewTag(t *testing.T) {
+	ctx := context.Background()
+	srcClient := newTestClient(t)
+	dstClient := newTestClient(t)
+	writeTestImage(t, srcClient, "library/app", "v1")
+
+	src, err := ResolveSource(ctx, srcClient, "library/app", "v1", nil, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeSignatureTag(t, srcClient, "library/app", src.Digest, [2]string{"payload", "c2ln"})
+
+	logf := func(format string, a ...interface{}) {}
+	for i, want := range []int{1, 0} {
+		copied, err := CopyArtifacts(ctx, src, srcClient, dstClient, "library/app", "mirror/app", true, false, logf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if copied != want {
+			t.Errorf("第 %d 次 copied = %d, want %d", i+1, copied, want)
+		}
+	}
+
+	srcDigest, err := srcClient.GetDigest(ctx, "library/app", signature.SignatureTag(src.Digest))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dstDigest, err := dstClient.GetDigest(ctx, "mirror/app", signature.SignatureTag(src.Digest))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if srcDigest != dstDigest {
+		t.Errorf("目标不存在时应原样复制: %s != %s", dstDigest, srcDigest)
+	}
+}
+
+// TestCopyReferrersHeadError 目标仓库检查 referrer 时返回非 404 错误应报错，而不是当作不存在重新推送
+func TestCopyReferrersHeadError(t *testing.T) {
+	ctx := context.Background()
+	srcClient := newTestClient(t)
+	img := writeTestImage(t, srcClient, "library/app", "v1")
+
+	// 在源仓库推送一个以镜像为 subject 的制品
+	subject, err := partial.Descriptor(img)
+	if err != nil {
+		t.Fatal(err)
+	}
+	artifact, err := random.Image(64, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	artifact = mutate.Subject(artifact, *subject).(v1.Image)
+	artifactDigest, err := artifact.Digest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	srcRepo, err := name.NewRepository(srcClient.URL+"/library/app", getNameOptions(true)...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := remote.Write(srcRepo.Digest(artifactDigest.String()), artifact, srcClient.GetOptions()...); err != nil {
+		t.Fatal(err)
+	}
+
+	// 目标仓库在 failHead 置位时对按 digest 的 HEAD 请求返回 500
+	var failHead atomic.Bool
+	handler := ggcrregistry.New(ggcrregistry.Logger(log.New(io.Discard, "", 0)))
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if failHead.Load() && r.Method == http.MethodHead && strings.Contains(r.URL.Path, "/manifests/sha256:") {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	}))
+	t.Cleanup(srv.Close)
+	dstClient, err := NewClient(strings.TrimPrefix(srv.URL, "http://"), "", "", tlsutil.Options{Insecure: true}, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	src, err := ResolveSource(ctx, srcClient, "library/app", "v1", nil, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := PushImage(ctx, src, dstClient, "mirror/app", "v1", nil, false); err != nil {
+		t.Fatal(err)
+	}
+
+	logf := func(format string, a ...interface{}) {}
+	failHead.Store(true)
+	copied, err := CopyArtifacts(ctx, src, srcClient, dstClient, "library/app", "mirror/app", false, true, logf)
+	if err == nil || !strings.Contains(err.Error(), "检查目标仓库的 referrer") || copied != 0 {
+		t.Errorf("copied = %d, err = %v, want 检查目标仓库的 referrer 失败", copied, err)
+	}
+
+	failHead.Store(false)
+	for i, want := range []int{1, 0} {
+		copied, err := CopyArtifacts(ctx, src, srcClient, dstClient, "library/app", "mirror/app", false, true, logf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if copied != want {
+			t.Errorf("第 %d 次 copied = %d, want %d", i+1, copied, want)
+		}
+	}
+}
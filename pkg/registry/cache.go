@@ -0,0 +1,176 @@
+package registry
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+)
+
+// BlobCache 在本地临时目录缓存已拉取的层数据，
+// 同一个源镜像推送到多个目标仓库时，层只需从源仓库拉取一次
+type BlobCache struct {
+	dir string
+}
+
+// NewBlobCache 创建一个基于临时目录的层缓存，使用完毕后需调用 Close 清理
+func NewBlobCache() (*BlobCache, error) {
+	dir, err := os.MkdirTemp("", "ikl-blobs-")
+	if err != nil {
+		return nil, err
+	}
+	return &BlobCache{dir: dir}, nil
+}
+
+// Close 删除缓存目录
+func (c *BlobCache) Close() error {
+	return os.RemoveAll(c.dir)
+}
+
+// UseCache 让后续的 PushImage 通过 cache 读取层数据
+func (s *Source) UseCache(cache *BlobCache) {
+	if s.index != nil {
+		s.index = &cachedIndex{inner: s.index, cache: cache}
+	}
+	if s.image != nil {
+		s.image = &cachedImage{Image: s.image, cache: cache}
+	}
+}
+
+func (c *BlobCache) path(h v1.Hash) string {
+	return filepath.Join(c.dir, h.Algorithm+"-"+h.Hex)
+}
+
+// cachedIndex 包装 Index，使其子镜像的层经过缓存读取
+type cachedIndex struct {
+	inner v1.ImageIndex
+	cache *BlobCache
+}
+
+func (i *cachedIndex) MediaType() (types.MediaType, error) {
+	return i.inner.MediaType()
+}
+
+func (i *cachedIndex) Digest() (v1.Hash, error) {
+	return i.inner.Digest()
+}
+
+func (i *cachedIndex) Size() (int64, error) {
+	return i.inner.Size()
+}
+
+func (i *cachedIndex) IndexManifest() (*v1.IndexManifest, error) {
+	return i.inner.IndexManifest()
+}
+
+func (i *cachedIndex) RawManifest() ([]byte, error) {
+	return i.inner.RawManifest()
+}
+
+func (i *cachedIndex) Image(h v1.Hash) (v1.Image, error) {
+	img, err := i.inner.Image(h)
+	if err != nil {
+		return nil, err
+	}
+	return &cachedImage{Image: img, cache: i.cache}, nil
+}
+
+func (i *cachedIndex) ImageIndex(h v1.Hash) (v1.ImageIndex, error) {
+	idx, err := i.inner.ImageIndex(h)
+	if err != nil {
+		return nil, err
+	}
+	return &cachedIndex{inner: idx, cache: i.cache}, nil
+}
+
+// cachedImage 包装 Image，使其层经过缓存读取；Manifest 与配置保持原样
+type cachedImage struct {
+	v1.Image
+	cache *BlobCache
+}
+
+func (i *cachedImage) Layers() ([]v1.Layer, error) {
+	layers, err := i.Image.Layers()
+	if err != nil {
+		return nil, err
+	}
+	wrapped := make([]v1.Layer, len(layers))
+	for idx, l := range layers {
+		wrapped[idx] = &cachedLayer{Layer: l, cache: i.cache}
+	}
+	return wrapped, nil
+}
+
+func (i *cachedImage) LayerByDigest(h v1.Hash) (v1.Layer, error) {
+	l, err := i.Image.LayerByDigest(h)
+	if err != nil {
+		return nil, err
+	}
+	return &cachedLayer{Layer: l, cache: i.cache}, nil
+}
+
+// cachedLayer 原样缓存压缩后的层数据，digest、大小与媒体类型仍取自原始层
+type cachedLayer struct {
+	v1.Layer
+	cache *BlobCache
+}
+
+func (l *cachedLayer) Compressed() (io.ReadCloser, error) {
+	digest, err := l.Digest()
+	if err != nil {
+		return nil, err
+	}
+	path := l.cache.path(digest)
+
+	if f, err := os.Open(path); err == nil {
+		return f, nil
+	}
+
+	rc, err := l.Layer.Compressed()
+	if err != nil {
+		return nil, err
+	}
+	tmp, err := os.CreateTemp(l.cache.dir, "partial-")
+	if err != nil {
+		rc.Close()
+		return nil, err
+	}
+	return &teeReadCloser{src: rc, tmp: tmp, path: path}, nil
+}
+
+// teeReadCloser 在读取源数据的同时写入临时文件，完整读取到 EOF 后才重命名为缓存文件，
+// 避免传输中断时留下不完整的缓存
+type teeReadCloser struct {
+	src      io.ReadCloser
+	tmp      *os.File
+	path     string
+	complete bool
+	failed   bool
+}
+
+func (t *teeReadCloser) Read(p []byte) (int, error) {
+	n, err := t.src.Read(p)
+	if n > 0 && !t.failed {
+		if _, werr := t.tmp.Write(p[:n]); werr != nil {
+			t.failed = true
+		}
+	}
+	if err == io.EOF {
+		t.complete = true
+	}
+	return n, err
+}
+
+func (t *teeReadCloser) Close() error {
+	err := t.src.Close()
+	t.tmp.Close()
+	if t.complete && !t.failed {
+		if os.Rename(t.tmp.Name(), t.path) == nil {
+			return err
+		}
+	}
+	os.Remove(t.tmp.Name())
+	return err
+}
@@ -3,11 +3,13 @@ package registry
 import (
 	"bytes"
 	"context"
-	"crypto/tls"
 	"encoding/json"
 	"fmt"
+	"ikl/pkg/credentials"
+	"ikl/pkg/httptransport"
+	"ikl/pkg/platform"
+	"ikl/pkg/tlsutil"
 	"net/http"
-	"net/url"
 	"strings"
 	"time"
 
@@ -21,12 +23,12 @@ import (
 
 // TagDetail 包含镜像标签的详细信息
 type TagDetail struct {
-	Name          string
-	Digest        string
-	Architectures []string
-	Size          int64
-	Created       time.Time
-	IsIndex       bool
+	Name          string    `json:"name" yaml:"name"`
+	Digest        string    `json:"digest" yaml:"digest"`
+	Architectures []string  `json:"architectures" yaml:"architectures"`
+	Size          int64     `json:"size" yaml:"size"`
+	Created       time.Time `json:"created,omitzero" yaml:"created,omitempty"`
+	IsIndex       bool      `json:"isIndex" yaml:"isIndex"`
 }
 
 type Client struct {
@@ -34,60 +36,104 @@ type Client struct {
 	Authenticator authn.Authenticator
 	Transport     *http.Transport
 	Insecure      bool
+
+	limiter *rateLimiter // 为空时不限速
 }
 
-func NewClient(registryURL, username, password string, insecure bool, proxyURL string, noProxy string) (*Client, error) {
-	auth := authn.FromConfig(authn.AuthConfig{
+// NewClient 创建仓库客户端，未指定账号密码时回退到 docker/podman 的凭据存储 (含 docker-credential-* 凭据助手)
+// tlsOpts.Insecure 同时表示允许使用 HTTP 访问仓库
+func NewClient(registryURL, username, password string, tlsOpts tlsutil.Options, proxyURL string, noProxy string) (*Client, error) {
+	authCfg := authn.AuthConfig{
 		Username: username,
 		Password: password,
-	})
-
-	t := remote.DefaultTransport.(*http.Transport).Clone()
-
-	if insecure {
-		t.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
 	}
-
-	if proxyURL != "" {
-		proxyEndpoint, err := url.Parse(proxyURL)
+	if username == "" && password == "" {
+		cred, err := credentials.Lookup(registryURL)
 		if err != nil {
-			return nil, fmt.Errorf("无效的代理地址: %w", err)
+			return nil, err
 		}
-
-		noProxyList := strings.Split(noProxy, ",")
-		for i := range noProxyList {
-			noProxyList[i] = strings.TrimSpace(noProxyList[i])
+		authCfg = authn.AuthConfig{
+			Username:      cred.Username,
+			Password:      cred.Password,
+			IdentityToken: cred.IdentityToken,
 		}
+	}
+	auth := authn.FromConfig(authCfg)
 
-		t.Proxy = func(req *http.Request) (*url.URL, error) {
-			host := req.URL.Hostname()
-			for _, np := range noProxyList {
-				if np == "" {
-					continue
-				}
-				if host == np || strings.HasSuffix(host, "."+np) {
-					return nil, nil
-				}
-			}
-			return proxyEndpoint, nil
-		}
+	t, err := httptransport.New(httptransport.Options{
+		TLS:     tlsOpts,
+		Proxy:   proxyURL,
+		NoProxy: noProxy,
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	return &Client{
 		URL:           registryURL,
 		Authenticator: auth,
 		Transport:     t,
-		Insecure:      insecure,
+		Insecure:      tlsOpts.Insecure,
 	}, nil
 }
 
+// SetRateLimit 为客户端启用限速，并在仓库返回的剩余拉取次数 (Docker Hub ratelimit-remaining) 用完时主动暂停
+// logf 用于输出暂停日志，可为空
+func (c *Client) SetRateLimit(limit RateLimit, logf func(format string, a ...interface{})) {
+	c.limiter = newRateLimiter(limit, logf)
+}
+
 func (c *Client) GetOptions() []remote.Option {
 	return []remote.Option{
 		remote.WithAuth(c.Authenticator),
-		remote.WithTransport(c.Transport),
+		remote.WithTransport(c.roundTripper()),
+		// 重试统一由 RetryPolicy 负责，关闭 ggcr 内置的按状态码重试与上传重试，避免重试次数叠加且统计不准
+		remote.WithRetryStatusCodes(),
+		remote.WithRetryBackoff(remote.Backoff{Steps: 1}),
 	}
 }
 
+// roundTripper 返回带限速与 Retry-After 记录的传输层
+func (c *Client) roundTripper() http.RoundTripper {
+	var rt http.RoundTripper = c.Transport
+	if c.limiter != nil {
+		rt = &rateLimitTransport{inner: rt, limiter: c.limiter}
+	}
+	return &retryAfterTransport{inner: rt}
+}
+
+// Ping 访问仓库的 /v2/ 接口，校验仓库可访问且认证信息有效
+func (c *Client) Ping(ctx context.Context) error {
+	reg, err := name.NewRegistry(c.URL, getNameOptions(c.Insecure)...)
+	if err != nil {
+		return fmt.Errorf("解析仓库地址失败: %w", err)
+	}
+
+	// 使用 Token 认证的仓库在换取 Token 时即会校验账号密码
+	rt, err := transport.NewWithContext(ctx, reg, c.Authenticator, c.Transport, []string{reg.Scope(transport.PullScope)})
+	if err != nil {
+		return fmt.Errorf("认证失败: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s://%s/v2/", reg.Scheme(), reg.RegistryStr()), nil)
+	if err != nil {
+		return err
+	}
+	resp, err := (&http.Client{Transport: rt}).Do(req)
+	if err != nil {
+		return fmt.Errorf("连接仓库失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return nil
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return fmt.Errorf("认证失败: 仓库返回 HTTP %d", resp.StatusCode)
+	}
+	return transport.CheckError(resp, http.StatusOK)
+}
+
 func (c *Client) ListRepositories(ctx context.Context) ([]string, error) {
 	regOpts := []name.Option{}
 	if c.Insecure {
@@ -129,7 +175,7 @@ func (c *Client) ListTags(ctx context.Context, repoName string) ([]string, error
 }
 
 func (c *Client) GetTagDetail(ctx context.Context, repoName, tag string) (*TagDetail, error) {
-	refStr := fmt.Sprintf("%s/%s:%s", c.URL, repoName, tag)
+	refStr := RefString(c.URL, repoName, tag)
 	refOpts := getNameOptions(c.Insecure)
 
 	ref, err := name.ParseReference(refStr, refOpts...)
@@ -157,11 +203,7 @@ func (c *Client) GetTagDetail(ctx context.Context, repoName, tag string) (*TagDe
 		manifest, err := idx.IndexManifest()
 		if err == nil {
 			for _, m := range manifest.Manifests {
-				if m.Platform != nil && m.Platform.Architecture != "" && m.Platform.Architecture != "unknown" {
-					arch := fmt.Sprintf("%s/%s", m.Platform.OS, m.Platform.Architecture)
-					if m.Platform.Variant != "" {
-						arch += "/" + m.Platform.Variant
-					}
+				if arch := platform.Format(m.Platform); arch != "" {
 					exists := false
 					for _, a := range detail.Architectures {
 						if a == arch {
@@ -189,7 +231,9 @@ func (c *Client) GetTagDetail(ctx context.Context, repoName, tag string) (*TagDe
 			config, err := img.ConfigFile()
 			if err == nil {
 				detail.Created = config.Created.Time
-				detail.Architectures = []string{fmt.Sprintf("%s/%s", config.OS, config.Architecture)}
+				if arch := platform.Format(config.Platform()); arch != "" {
+					detail.Architectures = []string{arch}
+				}
 			}
 			if layers, err := img.Layers(); err == nil {
 				var size int64
@@ -205,109 +249,283 @@ func (c *Client) GetTagDetail(ctx context.Context, repoName, tag string) (*TagDe
 	return detail, nil
 }
 
+// GetDigest 通过 HEAD 请求获取镜像 Manifest 的 digest，镜像不存在时返回空字符串
+// tag 也可以是 digest (sha256:...)
+func (c *Client) GetDigest(ctx context.Context, repoName, tag string) (string, error) {
+	refStr := RefString(c.URL, repoName, tag)
+
+	ref, err := name.ParseReference(refStr, getNameOptions(c.Insecure)...)
+	if err != nil {
+		return "", err
+	}
+
+	desc, err := remote.Head(ref, append(c.GetOptions(), remote.WithContext(ctx))...)
+	if err != nil {
+		if tErr, ok := err.(*transport.Error); ok && tErr.StatusCode == http.StatusNotFound {
+			return "", nil
+		}
+		return "", err
+	}
+	return desc.Digest.String(), nil
+}
+
+// BlobExists 通过 HEAD 请求检查仓库中是否已存在指定的 blob (层或配置)
+func (c *Client) BlobExists(ctx context.Context, repoName string, digest v1.Hash) (bool, error) {
+	repo, err := name.NewRepository(fmt.Sprintf("%s/%s", c.URL, repoName), getNameOptions(c.Insecure)...)
+	if err != nil {
+		return false, fmt.Errorf("解析镜像名失败: %w", err)
+	}
+
+	rt, err := transport.NewWithContext(ctx, repo.Registry, c.Authenticator, c.Transport, []string{repo.Scope(transport.PullScope)})
+	if err != nil {
+		return false, err
+	}
+
+	u := fmt.Sprintf("%s://%s/v2/%s/blobs/%s", repo.Scheme(), repo.RegistryStr(), repo.RepositoryStr(), digest)
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, u, nil)
+	if err != nil {
+		return false, err
+	}
+	resp, err := (&http.Client{Transport: rt}).Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return true, nil
+	case http.StatusNotFound:
+		return false, nil
+	}
+	return false, transport.CheckError(resp, http.StatusOK)
+}
+
+// CopyResult 描述一次镜像复制的结果
+type CopyResult struct {
+	SourceDigest string // 源镜像 Manifest 的 digest
+	Digest       string // 推送到目标仓库的 Manifest digest (架构筛选后可能与源不同)
+	UpToDate     bool   // 目标 Tag 已指向相同的 Manifest，未实际传输
+
+	BytesTransferred int64 // 实际上传的层与配置字节数，不含目标仓库已存在或跨仓库挂载的 blob
+}
+
 // CopyImage 支持进度条回调和架构筛选
 // 修改：imageName 改为 srcRepo 和 dstRepo，允许重命名
-func CopyImage(ctx context.Context, srcClient, dstClient *Client, srcRepo, dstRepo, tag string, progressCh chan<- v1.Update, platforms []string) error {
-	srcRefStr := fmt.Sprintf("%s/%s:%s", srcClient.URL, srcRepo, tag)
-	dstRefStr := fmt.Sprintf("%s/%s:%s", dstClient.URL, dstRepo, tag)
-
-	srcRef, err := name.ParseReference(srcRefStr, getNameOptions(srcClient.Insecure)...)
+// 推送前会先 HEAD 目标 Tag，若已指向预期的 digest 则跳过传输；force 为 true 时强制复制
+func CopyImage(ctx context.Context, srcClient, dstClient *Client, srcRepo, dstRepo, tag string, progressCh chan<- v1.Update, platforms []string, force bool) (*CopyResult, error) {
+	src, err := ResolveSource(ctx, srcClient, srcRepo, tag, platforms, false)
 	if err != nil {
-		return fmt.Errorf("解析源镜像地址失败: %w", err)
+		return nil, err
 	}
+	return PushImage(ctx, src, dstClient, dstRepo, tag, progressCh, force)
+}
 
+// PushImage 将已解析的源镜像推送到目标仓库，同一个 Source 可依次推送到多个目标
+// tag 为 digest (sha256:...) 时仅按 digest 推送，不创建 Tag；按 digest 固定的源镜像推送后会校验目标 digest
+func PushImage(ctx context.Context, src *Source, dstClient *Client, dstRepo, tag string, progressCh chan<- v1.Update, force bool) (*CopyResult, error) {
+	dstRefStr := RefString(dstClient.URL, dstRepo, tag)
 	dstRef, err := name.ParseReference(dstRefStr, getNameOptions(dstClient.Insecure)...)
 	if err != nil {
-		return fmt.Errorf("解析目标镜像地址失败: %w", err)
+		return nil, fmt.Errorf("解析目标镜像地址失败: %w", err)
 	}
 
-	desc, err := remote.Get(srcRef, srcClient.GetOptions()...)
-	if err != nil {
-		return fmt.Errorf("拉取源镜像清单失败: %w", err)
+	result := &CopyResult{
+		SourceDigest: src.SourceDigest,
+		Digest:       src.Digest,
+	}
+
+	// 预检：目标 Tag 已指向相同 Manifest 时无需重复推送
+	if !force {
+		current, err := dstClient.GetDigest(ctx, dstRepo, tag)
+		if err == nil && current == src.Digest {
+			result.UpToDate = true
+			return result, nil
+		}
 	}
 
-	writeOpts := dstClient.GetOptions()
+	// 后设置的 WithTransport 覆盖 GetOptions 中的传输层
+	counter := &uploadCounter{inner: dstClient.roundTripper()}
+	writeOpts := append(dstClient.GetOptions(), remote.WithTransport(counter))
 	if progressCh != nil {
 		writeOpts = append(writeOpts, remote.WithProgress(progressCh))
 	}
 
+	if src.index != nil {
+		if err := remote.WriteIndex(dstRef, src.index, writeOpts...); err != nil {
+			return nil, fmt.Errorf("推送到目标仓库失败 (Index): %w", err)
+		}
+	} else {
+		if err := remote.Write(dstRef, src.image, writeOpts...); err != nil {
+			return nil, fmt.Errorf("推送到目标仓库失败 (Image): %w", err)
+		}
+	}
+
+	if src.pinned != "" {
+		if err := verifyPinned(ctx, dstClient, dstRepo, tag, src.pinned); err != nil {
+			return nil, err
+		}
+	}
+
+	result.BytesTransferred = counter.bytes.Load()
+	return result, nil
+}
+
+// verifyPinned 校验推送后目标仓库中的 Manifest digest 与固定的 digest 一致
+func verifyPinned(ctx context.Context, dstClient *Client, dstRepo, tag, pinned string) error {
+	current, err := dstClient.GetDigest(ctx, dstRepo, tag)
+	if err != nil {
+		return fmt.Errorf("校验目标镜像 digest 失败: %w", err)
+	}
+	if current != pinned {
+		return fmt.Errorf("目标镜像 digest %s 与固定的 digest %s 不一致", current, pinned)
+	}
+	return nil
+}
+
+// Source 是按架构筛选后、待推送到目标仓库的源镜像，image 与 index 二选一
+type Source struct {
+	SourceDigest string   // 源镜像 Manifest 的 digest
+	Digest       string   // 筛选后待推送的 Manifest digest
+	Platforms    []string // 保留的平台，如 linux/amd64
+	Subjects     []string // 推送后在目标仓库中 digest 不变的 Manifest，其签名、证明与 SBOM 可以一并复制
+	image        v1.Image
+	index        v1.ImageIndex
+	pinned       string // 按 digest 固定时的 digest，推送后需校验
+}
+
+// ResolveSource 拉取源镜像清单并应用架构筛选，仅读取 Manifest，层数据在推送时按需拉取
+// tag 也可以是 digest (sha256:...)，此时推送的 Manifest 必须与该 digest 一致，因此不能再按架构筛选
+// keepAttestations 为 true 时保留引用已保留平台的 attestation manifest (BuildKit 生成的 SBOM/provenance)
+func ResolveSource(ctx context.Context, srcClient *Client, srcRepo, tag string, platforms []string, keepAttestations bool) (*Source, error) {
+	specs, err := platform.ParseAll(platforms)
+	if err != nil {
+		return nil, err
+	}
+
+	srcRefStr := RefString(srcClient.URL, srcRepo, tag)
+	srcRef, err := name.ParseReference(srcRefStr, getNameOptions(srcClient.Insecure)...)
+	if err != nil {
+		return nil, fmt.Errorf("解析源镜像地址失败: %w", err)
+	}
+
+	desc, err := remote.Get(srcRef, append(srcClient.GetOptions(), remote.WithContext(ctx))...)
+	if err != nil {
+		return nil, fmt.Errorf("拉取源镜像清单失败: %w", err)
+	}
+
+	src := &Source{
+		SourceDigest: desc.Digest.String(),
+		Digest:       desc.Digest.String(),
+	}
+	if _, ok := srcRef.(name.Digest); ok {
+		if len(platforms) > 0 {
+			return nil, fmt.Errorf("按 digest 固定的镜像不能再按架构 %v 筛选，否则推送的 digest 会改变", platforms)
+		}
+		src.pinned = srcRef.Identifier()
+		if desc.Digest.String() != src.pinned {
+			return nil, fmt.Errorf("源仓库返回的 digest %s 与固定的 digest %s 不一致", desc.Digest, src.pinned)
+		}
+	}
+
 	if desc.MediaType.IsIndex() {
 		idx, err := desc.ImageIndex()
 		if err != nil {
-			return fmt.Errorf("解析 Image Index 失败: %w", err)
+			return nil, fmt.Errorf("解析 Image Index 失败: %w", err)
 		}
 
-		if len(platforms) > 0 {
-			manifest, err := idx.IndexManifest()
-			if err != nil {
-				return err
-			}
+		manifest, err := idx.IndexManifest()
+		if err != nil {
+			return nil, err
+		}
 
-			var kept []v1.Descriptor
+		kept := manifest.Manifests
+		if len(platforms) > 0 {
+			kept = nil
 			for _, m := range manifest.Manifests {
-				if m.Platform == nil {
-					continue
-				}
-				for _, p := range platforms {
-					if strings.Contains(m.Platform.Architecture, p) || strings.Contains(fmt.Sprintf("%s/%s", m.Platform.OS, m.Platform.Architecture), p) {
-						kept = append(kept, m)
-						break
-					}
+				if platform.MatchAny(specs, m.Platform) {
+					kept = append(kept, m)
 				}
 			}
 
 			if len(kept) == 0 {
-				return fmt.Errorf("未找到符合架构 %v 的镜像", platforms)
+				return nil, fmt.Errorf("未找到符合架构 %v 的镜像", platforms)
+			}
+			if keepAttestations {
+				kept = append(kept, attestationsFor(manifest.Manifests, kept)...)
 			}
+		}
+		src.Platforms = platformNames(kept)
+		for _, m := range kept {
+			src.Subjects = append(src.Subjects, m.Digest.String())
+		}
 
-			if len(kept) == 1 {
-				childImg, err := idx.Image(kept[0].Digest)
-				if err != nil {
-					return err
-				}
-				return remote.Write(dstRef, childImg, writeOpts...)
+		if len(platforms) > 0 && len(kept) == 1 {
+			childImg, err := idx.Image(kept[0].Digest)
+			if err != nil {
+				return nil, err
 			}
+			src.image = childImg
+			src.Digest = kept[0].Digest.String()
+			return src, nil
+		}
 
+		if len(platforms) > 0 {
 			// 使用更新后的 filteredIndex
 			idx = &filteredIndex{
 				inner: idx,
 				kept:  kept,
 			}
+			digest, err := idx.Digest()
+			if err != nil {
+				return nil, err
+			}
+			src.Digest = digest.String()
 		}
-
-		err = remote.WriteIndex(dstRef, idx, writeOpts...)
-		if err != nil {
-			return fmt.Errorf("推送到目标仓库失败 (Index): %w", err)
+		if src.Digest == src.SourceDigest {
+			src.Subjects = append([]string{src.Digest}, src.Subjects...)
 		}
+		src.index = idx
 	} else {
 		img, err := desc.Image()
 		if err != nil {
-			return fmt.Errorf("解析 Image 失败: %w", err)
+			return nil, fmt.Errorf("解析 Image 失败: %w", err)
 		}
 
-		if len(platforms) > 0 {
-			cfg, err := img.ConfigFile()
-			if err == nil {
-				matched := false
-				for _, p := range platforms {
-					if strings.Contains(cfg.Architecture, p) {
-						matched = true
-						break
-					}
-				}
-				if !matched {
-					return fmt.Errorf("镜像架构 %s 不匹配目标 %v", cfg.Architecture, platforms)
-				}
+		cfg, err := img.ConfigFile()
+		if err == nil {
+			name := platform.Format(cfg.Platform())
+			if len(specs) > 0 && !platform.MatchAny(specs, cfg.Platform()) {
+				return nil, fmt.Errorf("镜像平台 %s 不匹配目标 %v", name, platforms)
+			}
+			if name != "" {
+				src.Platforms = []string{name}
 			}
 		}
+		src.Subjects = []string{src.Digest}
+		src.image = img
+	}
 
-		err = remote.Write(dstRef, img, writeOpts...)
-		if err != nil {
-			return fmt.Errorf("推送到目标仓库失败 (Image): %w", err)
+	return src, nil
+}
+
+// platformNames 将 Index 中的描述符转换为 os/arch[/variant] 形式的平台名称
+func platformNames(descs []v1.Descriptor) []string {
+	var names []string
+	for _, d := range descs {
+		if name := platform.Format(d.Platform); name != "" {
+			names = append(names, name)
 		}
 	}
+	return names
+}
 
-	return nil
+// RefString 拼接镜像地址，ref 为 Tag 或 digest (sha256:...)
+func RefString(registryURL, repo, ref string) string {
+	if strings.Contains(ref, ":") {
+		return fmt.Sprintf("%s/%s@%s", registryURL, repo, ref)
+	}
+	return fmt.Sprintf("%s/%s:%s", registryURL, repo, ref)
 }
 
 func getNameOptions(insecure bool) []name.Option {
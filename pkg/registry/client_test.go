@@ -0,0 +1,27 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// TestResolveSourceContext 拉取源镜像清单时使用调用方的 context，取消后立即返回
+func TestResolveSourceContext(t *testing.T) {
+	c := newTestClient(t)
+	writeTestImage(t, c, "library/app", "v1")
+
+	src, err := ResolveSource(context.Background(), c, "library/app", "v1", nil, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if src.Digest == "" {
+		t.Error("Digest is empty")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := ResolveSource(ctx, c, "library/app", "v1", nil, false); !errors.Is(err, context.Canceled) {
+		t.Errorf("ResolveSource() with canceled context error = %v, want context.Canceled", err)
+	}
+}
@@ -0,0 +1,256 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+	"ikl/pkg/platform"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+)
+
+// maxInspectDepth 限制嵌套 Index 的展开层数
+const maxInspectDepth = 3
+
+// ManifestDetail 是 inspect 输出的 Manifest 或 Image Index，Index 的各平台 Manifest 保存在 Manifests 中
+type ManifestDetail struct {
+	Digest       string            `json:"digest" yaml:"digest"`
+	MediaType    string            `json:"mediaType" yaml:"mediaType"`
+	Size         int64             `json:"size" yaml:"size"`
+	Platform     string            `json:"platform,omitempty" yaml:"platform,omitempty"`
+	ArtifactType string            `json:"artifactType,omitempty" yaml:"artifactType,omitempty"`
+	Subject      string            `json:"subject,omitempty" yaml:"subject,omitempty"` // referrers 引用的 Manifest digest
+	Annotations  map[string]string `json:"annotations,omitempty" yaml:"annotations,omitempty"`
+	Manifests    []*ManifestDetail `json:"manifests,omitempty" yaml:"manifests,omitempty"`
+	Config       *ConfigDetail     `json:"config,omitempty" yaml:"config,omitempty"`
+	Layers       []LayerDetail     `json:"layers,omitempty" yaml:"layers,omitempty"`
+	Error        string            `json:"error,omitempty" yaml:"error,omitempty"` // 读取子 Manifest 或配置失败时的错误
+}
+
+// IsIndex 判断是否为 Image Index / Manifest List
+func (m *ManifestDetail) IsIndex() bool {
+	return types.MediaType(m.MediaType).IsIndex()
+}
+
+// LayerDetail 描述镜像的一个层
+type LayerDetail struct {
+	Digest      string            `json:"digest" yaml:"digest"`
+	MediaType   string            `json:"mediaType" yaml:"mediaType"`
+	Size        int64             `json:"size" yaml:"size"`
+	Compression string            `json:"compression,omitempty" yaml:"compression,omitempty"` // gzip、zstd、none，非镜像层时为空
+	Annotations map[string]string `json:"annotations,omitempty" yaml:"annotations,omitempty"`
+}
+
+// ConfigDetail 是镜像配置中与运行相关的字段
+type ConfigDetail struct {
+	Digest       string            `json:"digest" yaml:"digest"`
+	MediaType    string            `json:"mediaType" yaml:"mediaType"`
+	Size         int64             `json:"size" yaml:"size"`
+	Created      time.Time         `json:"created,omitzero" yaml:"created,omitempty"`
+	Author       string            `json:"author,omitempty" yaml:"author,omitempty"`
+	Platform     string            `json:"platform,omitempty" yaml:"platform,omitempty"`
+	User         string            `json:"user,omitempty" yaml:"user,omitempty"`
+	WorkingDir   string            `json:"workingDir,omitempty" yaml:"workingDir,omitempty"`
+	Env          []string          `json:"env,omitempty" yaml:"env,omitempty"`
+	Entrypoint   []string          `json:"entrypoint,omitempty" yaml:"entrypoint,omitempty"`
+	Cmd          []string          `json:"cmd,omitempty" yaml:"cmd,omitempty"`
+	ExposedPorts []string          `json:"exposedPorts,omitempty" yaml:"exposedPorts,omitempty"`
+	Volumes      []string          `json:"volumes,omitempty" yaml:"volumes,omitempty"`
+	StopSignal   string            `json:"stopSignal,omitempty" yaml:"stopSignal,omitempty"`
+	Labels       map[string]string `json:"labels,omitempty" yaml:"labels,omitempty"`
+	History      []HistoryDetail   `json:"history,omitempty" yaml:"history,omitempty"`
+}
+
+// HistoryDetail 是镜像构建历史中的一步
+type HistoryDetail struct {
+	Created    time.Time `json:"created,omitzero" yaml:"created,omitempty"`
+	CreatedBy  string    `json:"createdBy,omitempty" yaml:"createdBy,omitempty"`
+	Comment    string    `json:"comment,omitempty" yaml:"comment,omitempty"`
+	EmptyLayer bool      `json:"emptyLayer,omitempty" yaml:"emptyLayer,omitempty"`
+}
+
+// RawManifest 返回仓库中保存的原始 Manifest，ref 为 Tag 或 digest (sha256:...)
+func (c *Client) RawManifest(ctx context.Context, repoName, ref string) ([]byte, error) {
+	_, desc, err := c.getManifest(ctx, repoName, ref)
+	if err != nil {
+		return nil, err
+	}
+	return desc.Manifest, nil
+}
+
+// Inspect 读取 Manifest 或 Image Index 的完整结构，Index 会展开各平台的 Manifest 及其镜像配置
+// 子 Manifest 读取失败时记录在其 Error 中，不影响其他条目
+func (c *Client) Inspect(ctx context.Context, repoName, ref string) (*ManifestDetail, error) {
+	r, desc, err := c.getManifest(ctx, repoName, ref)
+	if err != nil {
+		return nil, err
+	}
+	detail := &ManifestDetail{
+		Digest:    desc.Digest.String(),
+		MediaType: string(desc.MediaType),
+		Size:      desc.Size,
+	}
+	if err := c.inspectDescriptor(ctx, r.Context(), desc, detail, 0); err != nil {
+		return nil, err
+	}
+	return detail, nil
+}
+
+func (c *Client) getManifest(ctx context.Context, repoName, ref string) (name.Reference, *remote.Descriptor, error) {
+	r, err := name.ParseReference(RefString(c.URL, repoName, ref), getNameOptions(c.Insecure)...)
+	if err != nil {
+		return nil, nil, fmt.Errorf("解析镜像地址失败: %w", err)
+	}
+	desc, err := remote.Get(r, append(c.GetOptions(), remote.WithContext(ctx))...)
+	if err != nil {
+		return nil, nil, fmt.Errorf("获取镜像清单失败: %w", err)
+	}
+	return r, desc, nil
+}
+
+// inspectDescriptor 根据 Manifest 类型填充 detail，Index 递归展开子 Manifest
+func (c *Client) inspectDescriptor(ctx context.Context, repo name.Repository, desc *remote.Descriptor, detail *ManifestDetail, depth int) error {
+	if desc.MediaType.IsIndex() {
+		idx, err := desc.ImageIndex()
+		if err != nil {
+			return fmt.Errorf("解析 Image Index 失败: %w", err)
+		}
+		manifest, err := idx.IndexManifest()
+		if err != nil {
+			return fmt.Errorf("解析 Image Index 失败: %w", err)
+		}
+		detail.Annotations = mergeAnnotations(detail.Annotations, manifest.Annotations)
+		if manifest.Subject != nil {
+			detail.Subject = manifest.Subject.Digest.String()
+		}
+		for _, m := range manifest.Manifests {
+			child := &ManifestDetail{
+				Digest:       m.Digest.String(),
+				MediaType:    string(m.MediaType),
+				Size:         m.Size,
+				Platform:     platform.Format(m.Platform),
+				ArtifactType: m.ArtifactType,
+				Annotations:  m.Annotations,
+			}
+			detail.Manifests = append(detail.Manifests, child)
+			if m.MediaType.IsIndex() && depth+1 >= maxInspectDepth {
+				continue
+			}
+			childDesc, err := remote.Get(repo.Digest(m.Digest.String()), append(c.GetOptions(), remote.WithContext(ctx))...)
+			if err == nil {
+				err = c.inspectDescriptor(ctx, repo, childDesc, child, depth+1)
+			}
+			if err != nil {
+				child.Error = err.Error()
+			}
+		}
+		return nil
+	}
+
+	img, err := desc.Image()
+	if err != nil {
+		return fmt.Errorf("解析 Image 失败: %w", err)
+	}
+	manifest, err := img.Manifest()
+	if err != nil {
+		return fmt.Errorf("解析 Manifest 失败: %w", err)
+	}
+	detail.Annotations = mergeAnnotations(detail.Annotations, manifest.Annotations)
+	if manifest.Subject != nil {
+		detail.Subject = manifest.Subject.Digest.String()
+	}
+	for _, l := range manifest.Layers {
+		detail.Layers = append(detail.Layers, LayerDetail{
+			Digest:      l.Digest.String(),
+			MediaType:   string(l.MediaType),
+			Size:        l.Size,
+			Compression: layerCompression(l.MediaType),
+			Annotations: l.Annotations,
+		})
+	}
+
+	detail.Config = &ConfigDetail{
+		Digest:    manifest.Config.Digest.String(),
+		MediaType: string(manifest.Config.MediaType),
+		Size:      manifest.Config.Size,
+	}
+	// 签名、SBOM 等制品的配置不是镜像配置，只保留描述符
+	if !manifest.Config.MediaType.IsConfig() {
+		return nil
+	}
+	cf, err := img.ConfigFile()
+	if err != nil {
+		detail.Error = fmt.Sprintf("读取镜像配置失败: %v", err)
+		return nil
+	}
+	fillConfig(detail.Config, cf)
+	if detail.Platform == "" {
+		detail.Platform = detail.Config.Platform
+	}
+	return nil
+}
+
+// mergeAnnotations 合并 Index 中描述符的注解与 Manifest 自身的注解，后者优先
+func mergeAnnotations(desc, own map[string]string) map[string]string {
+	if len(own) == 0 {
+		return desc
+	}
+	merged := make(map[string]string, len(desc)+len(own))
+	for k, v := range desc {
+		merged[k] = v
+	}
+	for k, v := range own {
+		merged[k] = v
+	}
+	return merged
+}
+
+// fillConfig 从镜像配置中提取运行相关的字段
+func fillConfig(detail *ConfigDetail, cf *v1.ConfigFile) {
+	detail.Created = cf.Created.Time
+	detail.Author = cf.Author
+	detail.Platform = platform.Format(cf.Platform())
+	detail.User = cf.Config.User
+	detail.WorkingDir = cf.Config.WorkingDir
+	detail.Env = cf.Config.Env
+	detail.Entrypoint = cf.Config.Entrypoint
+	detail.Cmd = cf.Config.Cmd
+	detail.StopSignal = cf.Config.StopSignal
+	detail.Labels = cf.Config.Labels
+	for port := range cf.Config.ExposedPorts {
+		detail.ExposedPorts = append(detail.ExposedPorts, port)
+	}
+	sort.Strings(detail.ExposedPorts)
+	for volume := range cf.Config.Volumes {
+		detail.Volumes = append(detail.Volumes, volume)
+	}
+	sort.Strings(detail.Volumes)
+	for _, h := range cf.History {
+		detail.History = append(detail.History, HistoryDetail{
+			Created:    h.Created.Time,
+			CreatedBy:  h.CreatedBy,
+			Comment:    h.Comment,
+			EmptyLayer: h.EmptyLayer,
+		})
+	}
+}
+
+// layerCompression 根据层的 mediaType 判断压缩格式，非镜像层 (如签名、证明) 返回空字符串
+func layerCompression(mt types.MediaType) string {
+	s := string(mt)
+	switch {
+	case !mt.IsLayer():
+		return ""
+	case strings.HasSuffix(s, "+gzip"), strings.HasSuffix(s, ".tar.gzip"):
+		return "gzip"
+	case strings.HasSuffix(s, "+zstd"):
+		return "zstd"
+	default:
+		return "none"
+	}
+}
@@ -0,0 +1,79 @@
+package registry
+
+import (
+	"fmt"
+	"ikl/pkg/platform"
+	"strings"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/layout"
+	"github.com/google/go-containerregistry/pkg/v1/match"
+)
+
+// 写入 OCI image layout 时附加在 index.json 条目上的注解
+const (
+	// AnnotationRefName 是 OCI 规范定义的引用名称，这里记录 Tag
+	AnnotationRefName = "org.opencontainers.image.ref.name"
+	// AnnotationImageName 记录 镜像名:Tag (不含仓库地址)，ikl load 据此还原目标镜像
+	AnnotationImageName = "io.containerd.image.name"
+)
+
+// WriteLayout 将源镜像写入 OCI image layout
+// Manifest List 结构会被完整保留，已存在的 blob 不会重复写入；同名镜像会被替换而不是重复追加
+// tag 为 digest (sha256:...) 时镜像名称记录为 repo@digest
+func (s *Source) WriteLayout(p layout.Path, repo, tag string) error {
+	imageName := repo + ":" + tag
+	annotations := map[string]string{
+		AnnotationRefName:   tag,
+		AnnotationImageName: imageName,
+	}
+	if strings.Contains(tag, ":") {
+		imageName = repo + "@" + tag
+		annotations = map[string]string{
+			AnnotationImageName: imageName,
+		}
+	}
+	matcher := match.Annotation(AnnotationImageName, imageName)
+
+	if s.index != nil {
+		return p.ReplaceIndex(s.index, matcher, layout.WithAnnotations(annotations))
+	}
+	return p.ReplaceImage(s.image, matcher, layout.WithAnnotations(annotations))
+}
+
+// NewImageSource 将本地读取的单架构镜像包装为可推送的 Source
+func NewImageSource(img v1.Image) (*Source, error) {
+	digest, err := img.Digest()
+	if err != nil {
+		return nil, fmt.Errorf("计算镜像 digest 失败: %w", err)
+	}
+	src := &Source{
+		SourceDigest: digest.String(),
+		Digest:       digest.String(),
+		image:        img,
+	}
+	if cfg, err := img.ConfigFile(); err == nil {
+		if name := platform.Format(cfg.Platform()); name != "" {
+			src.Platforms = []string{name}
+		}
+	}
+	return src, nil
+}
+
+// NewIndexSource 将本地读取的 Manifest List 包装为可推送的 Source，保留全部平台
+func NewIndexSource(idx v1.ImageIndex) (*Source, error) {
+	digest, err := idx.Digest()
+	if err != nil {
+		return nil, fmt.Errorf("计算 Image Index digest 失败: %w", err)
+	}
+	manifest, err := idx.IndexManifest()
+	if err != nil {
+		return nil, fmt.Errorf("解析 Image Index 失败: %w", err)
+	}
+	return &Source{
+		SourceDigest: digest.String(),
+		Digest:       digest.String(),
+		Platforms:    platformNames(manifest.Manifests),
+		index:        idx,
+	}, nil
+}
@@ -0,0 +1,106 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+)
+
+// 迁移计划中的操作类型
+const (
+	PlanActionCreate = "create" // 目标 Tag 不存在
+	PlanActionUpdate = "update" // 目标 Tag 存在但指向不同的 Manifest
+	PlanActionSkip   = "skip"   // 目标 Tag 已指向相同的 Manifest
+)
+
+// CopyPlan 描述 dry-run 模式下单个镜像的迁移计划
+type CopyPlan struct {
+	SourceDigest string
+	Digest       string   // 预计推送到目标仓库的 Manifest digest
+	Platforms    []string // 架构筛选后保留的平台
+	Size         int64    // 镜像总大小 (层 + 配置)
+	Transfer     int64    // 预计传输的字节数，仅统计目标仓库中不存在的层与配置
+	Action       string   // create / update / skip
+}
+
+// PlanPush 按 PushImage 相同的规则检查目标仓库并估算传输量，但不写入任何数据
+// 传输量通过 HEAD 目标仓库的 blob 估算，已存在的层与配置不计入
+func PlanPush(ctx context.Context, src *Source, dstClient *Client, dstRepo, tag string, force bool) (*CopyPlan, error) {
+	plan := &CopyPlan{
+		SourceDigest: src.SourceDigest,
+		Digest:       src.Digest,
+		Platforms:    src.Platforms,
+	}
+
+	current, err := dstClient.GetDigest(ctx, dstRepo, tag)
+	if err != nil {
+		return nil, fmt.Errorf("检查目标镜像失败: %w", err)
+	}
+	switch {
+	case current == "":
+		plan.Action = PlanActionCreate
+	case current == src.Digest && !force:
+		plan.Action = PlanActionSkip
+		return plan, nil
+	default:
+		plan.Action = PlanActionUpdate
+	}
+
+	blobs, err := src.Blobs()
+	if err != nil {
+		return nil, fmt.Errorf("计算镜像大小失败: %w", err)
+	}
+	for _, b := range blobs {
+		plan.Size += b.Size
+		exists, err := dstClient.BlobExists(ctx, dstRepo, b.Digest)
+		if err != nil {
+			return nil, fmt.Errorf("检查目标仓库的 blob %s 失败: %w", b.Digest, err)
+		}
+		if !exists {
+			plan.Transfer += b.Size
+		}
+	}
+	return plan, nil
+}
+
+// Blobs 返回待推送镜像引用的所有层与配置，多个平台共享的 blob 只返回一次
+func (s *Source) Blobs() ([]v1.Descriptor, error) {
+	seen := make(map[v1.Hash]bool)
+	var blobs []v1.Descriptor
+	add := func(img v1.Image) error {
+		manifest, err := img.Manifest()
+		if err != nil {
+			return err
+		}
+		for _, d := range append([]v1.Descriptor{manifest.Config}, manifest.Layers...) {
+			if !seen[d.Digest] {
+				seen[d.Digest] = true
+				blobs = append(blobs, d)
+			}
+		}
+		return nil
+	}
+
+	if s.image != nil {
+		return blobs, add(s.image)
+	}
+
+	manifest, err := s.index.IndexManifest()
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range manifest.Manifests {
+		if !m.MediaType.IsImage() {
+			continue
+		}
+		img, err := s.index.Image(m.Digest)
+		if err != nil {
+			return nil, err
+		}
+		if err := add(img); err != nil {
+			return nil, err
+		}
+	}
+	return blobs, nil
+}
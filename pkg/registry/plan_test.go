@@ -0,0 +1,77 @@
+package registry
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+)
+
+func TestPlanPush(t *testing.T) {
+	ctx := context.Background()
+	c := newTestClient(t)
+	dst := newTestClient(t)
+	img := writeTestImage(t, c, "library/app", "v1")
+
+	src, err := ResolveSource(ctx, c, "library/app", "v1", nil, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	blobs, err := src.Blobs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var total int64
+	for _, b := range blobs {
+		total += b.Size
+	}
+
+	// 目标仓库预先存在一个层，该层不计入预计传输量
+	layers, err := img.Layers()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dstRepo, err := name.NewRepository(dst.URL+"/mirror/app", getNameOptions(dst.Insecure)...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := remote.WriteLayer(dstRepo, layers[0], dst.GetOptions()...); err != nil {
+		t.Fatal(err)
+	}
+	existing, err := layers[0].Size()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	plan, err := PlanPush(ctx, src, dst, "mirror/app", "v1", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plan.Action != PlanActionCreate {
+		t.Errorf("Action = %s, want %s", plan.Action, PlanActionCreate)
+	}
+	if plan.Size != total {
+		t.Errorf("Size = %d, want %d", plan.Size, total)
+	}
+	if plan.Transfer != total-existing {
+		t.Errorf("Transfer = %d, want %d", plan.Transfer, total-existing)
+	}
+
+	// 源仓库本身已包含所有层，无需传输
+	plan, err = PlanPush(ctx, src, c, "library/app", "v2", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plan.Transfer != 0 {
+		t.Errorf("Transfer = %d, want 0", plan.Transfer)
+	}
+
+	plan, err = PlanPush(ctx, src, c, "library/app", "v1", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plan.Action != PlanActionSkip {
+		t.Errorf("Action = %s, want %s", plan.Action, PlanActionSkip)
+	}
+}
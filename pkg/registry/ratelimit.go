@@ -0,0 +1,159 @@
+package registry
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RateLimit 定义对单个仓库的请求限速
+type RateLimit struct {
+	RPS       float64       // 每秒请求数上限，0 表示不限制
+	Manifests int           // 每个窗口内 Manifest 拉取 (GET) 次数上限，0 表示不限制
+	Window    time.Duration // Manifest 拉取次数的统计窗口，如 Docker Hub 匿名用户为 6h
+}
+
+// rateLimiter 在 Transport 层按 RateLimit 限速，并根据 Docker Hub 返回的 ratelimit-remaining 头主动暂停
+type rateLimiter struct {
+	limit RateLimit
+	logf  func(format string, a ...interface{})
+	now   func() time.Time
+
+	mu        sync.Mutex
+	next      time.Time   // 下一个请求最早可发出的时间，Manifest 拉取暂停时其他请求同样等待
+	pulls     []time.Time // 窗口内的 Manifest 拉取时间
+	remaining int         // 仓库返回的剩余拉取次数，-1 表示未知
+	interval  time.Duration
+}
+
+func newRateLimiter(limit RateLimit, logf func(format string, a ...interface{})) *rateLimiter {
+	return &rateLimiter{limit: limit, logf: logf, now: time.Now, remaining: -1}
+}
+
+// reserve 为请求预留发送时间，返回需要等待的时长与原因
+func (l *rateLimiter) reserve(manifestPull bool) (time.Duration, string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	at := now
+	reason := ""
+	if l.next.After(at) {
+		at = l.next
+	}
+
+	if manifestPull {
+		if l.limit.Manifests > 0 && l.limit.Window > 0 {
+			cutoff := now.Add(-l.limit.Window)
+			kept := l.pulls[:0]
+			for _, t := range l.pulls {
+				if t.After(cutoff) {
+					kept = append(kept, t)
+				}
+			}
+			l.pulls = kept
+			if len(l.pulls) >= l.limit.Manifests {
+				if free := l.pulls[len(l.pulls)-l.limit.Manifests].Add(l.limit.Window); free.After(at) {
+					at = free
+					reason = "已达到配置的 Manifest 拉取次数上限"
+				}
+			}
+		}
+		// 仓库告知剩余次数已用完时，按 窗口/总次数 的间隔等待额度逐步恢复
+		if l.remaining == 0 && l.interval > 0 {
+			if free := now.Add(l.interval); free.After(at) {
+				at = free
+				reason = "仓库返回的剩余拉取次数为 0"
+			}
+		}
+		if l.limit.Manifests > 0 && l.limit.Window > 0 {
+			l.pulls = append(l.pulls, at)
+		}
+	}
+
+	// 以最终的发送时间推进 next，避免并发的其他请求先于暂停中的 Manifest 拉取发出
+	l.next = at
+	if l.limit.RPS > 0 {
+		l.next = at.Add(time.Duration(float64(time.Second) / l.limit.RPS))
+	}
+
+	return at.Sub(now), reason
+}
+
+// observe 读取 Docker Hub 的 ratelimit-limit / ratelimit-remaining 响应头，如 "100;w=21600"
+func (l *rateLimiter) observe(resp *http.Response) {
+	remaining, _, ok := parseRateLimitHeader(resp.Header.Get("ratelimit-remaining"))
+	if !ok {
+		return
+	}
+	limit, window, limitOK := parseRateLimitHeader(resp.Header.Get("ratelimit-limit"))
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.remaining = remaining
+	if limitOK && limit > 0 && window > 0 {
+		l.interval = window / time.Duration(limit)
+	}
+}
+
+// parseRateLimitHeader 解析 "100;w=21600" 形式的响应头，返回次数与窗口
+func parseRateLimitHeader(v string) (int, time.Duration, bool) {
+	if v == "" {
+		return 0, 0, false
+	}
+	parts := strings.Split(v, ";")
+	n, err := strconv.Atoi(strings.TrimSpace(parts[0]))
+	if err != nil {
+		return 0, 0, false
+	}
+	var window time.Duration
+	for _, p := range parts[1:] {
+		if w, ok := strings.CutPrefix(strings.TrimSpace(p), "w="); ok {
+			if secs, err := strconv.Atoi(w); err == nil {
+				window = time.Duration(secs) * time.Second
+			}
+		}
+	}
+	return n, window, true
+}
+
+// rateLimitTransport 在发送请求前按 rateLimiter 等待
+type rateLimitTransport struct {
+	inner   http.RoundTripper
+	limiter *rateLimiter
+}
+
+func (t *rateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	manifestPull := req.Method == http.MethodGet && strings.Contains(req.URL.Path, "/manifests/")
+	wait, reason := t.limiter.reserve(manifestPull)
+	if wait > 0 {
+		if reason != "" && t.limiter.logf != nil {
+			t.limiter.logf("⏸️  %s: %s，暂停 %s\n", req.URL.Host, reason, wait.Round(time.Second))
+		}
+		if err := sleepContext(req.Context(), wait); err != nil {
+			return nil, err
+		}
+	}
+
+	resp, err := t.inner.RoundTrip(req)
+	if err != nil {
+		return resp, err
+	}
+	t.limiter.observe(resp)
+	return resp, nil
+}
+
+// sleepContext 等待 d，ctx 取消时提前返回
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
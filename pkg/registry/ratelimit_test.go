@@ -0,0 +1,131 @@
+package registry
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+// newTestLimiter 返回使用可控时钟的 rateLimiter，修改 *now 即可推进时间
+func newTestLimiter(limit RateLimit) (*rateLimiter, *time.Time) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	l := newRateLimiter(limit, nil)
+	l.now = func() time.Time { return now }
+	return l, &now
+}
+
+func TestRateLimiterRPS(t *testing.T) {
+	l, _ := newTestLimiter(RateLimit{RPS: 2})
+	for i, want := range []time.Duration{0, 500 * time.Millisecond, time.Second, 1500 * time.Millisecond} {
+		wait, reason := l.reserve(i%2 == 0)
+		if wait != want || reason != "" {
+			t.Errorf("第 %d 个请求 wait = %s (%q), want %s", i+1, wait, reason, want)
+		}
+	}
+	// 仅配置 rps 时不记录 Manifest 拉取时间
+	for i := 0; i < 100; i++ {
+		l.reserve(true)
+	}
+	if len(l.pulls) != 0 {
+		t.Errorf("未配置 manifests/window 时 pulls 长度 = %d, want 0", len(l.pulls))
+	}
+}
+
+func TestRateLimiterWindow(t *testing.T) {
+	l, now := newTestLimiter(RateLimit{Manifests: 2, Window: time.Minute})
+
+	for i := 0; i < 2; i++ {
+		if wait, _ := l.reserve(true); wait != 0 {
+			t.Errorf("第 %d 次拉取 wait = %s, want 0", i+1, wait)
+		}
+	}
+	wait, reason := l.reserve(true)
+	if wait != time.Minute || reason == "" {
+		t.Errorf("超出上限后 wait = %s (%q), want 1m", wait, reason)
+	}
+	// 其他请求不能先于暂停中的 Manifest 拉取发出
+	if wait, _ := l.reserve(false); wait != time.Minute {
+		t.Errorf("暂停期间的其他请求 wait = %s, want 1m", wait)
+	}
+
+	// 窗口过后最早的两次拉取被淘汰，只剩暂停后发出的一次
+	*now = now.Add(time.Minute + time.Second)
+	if wait, _ := l.reserve(true); wait != 0 {
+		t.Errorf("窗口过后 wait = %s, want 0", wait)
+	}
+	if len(l.pulls) != 2 {
+		t.Errorf("pulls 长度 = %d, want 2", len(l.pulls))
+	}
+}
+
+func TestRateLimiterRemaining(t *testing.T) {
+	l, _ := newTestLimiter(RateLimit{})
+
+	resp := &http.Response{Header: http.Header{}}
+	resp.Header.Set("RateLimit-Limit", "100;w=21600")
+	resp.Header.Set("RateLimit-Remaining", "0;w=21600")
+	l.observe(resp)
+
+	wait, reason := l.reserve(true)
+	if want := 216 * time.Second; wait != want || reason == "" {
+		t.Errorf("剩余次数为 0 时 wait = %s (%q), want %s", wait, reason, want)
+	}
+	if len(l.pulls) != 0 {
+		t.Errorf("未配置 manifests/window 时 pulls 长度 = %d, want 0", len(l.pulls))
+	}
+
+	resp.Header.Set("RateLimit-Remaining", "5;w=21600")
+	l.observe(resp)
+	// 之前暂停的拉取仍在等待，之后的请求排在其后
+	if wait, _ := l.reserve(false); wait != 216*time.Second {
+		t.Errorf("wait = %s, want 216s", wait)
+	}
+}
+
+func TestParseRateLimitHeader(t *testing.T) {
+	tests := []struct {
+		in     string
+		n      int
+		window time.Duration
+		ok     bool
+	}{
+		{"100;w=21600", 100, 6 * time.Hour, true},
+		{" 76 ; w=21600", 76, 6 * time.Hour, true},
+		{"200", 200, 0, true},
+		{"", 0, 0, false},
+		{"abc;w=60", 0, 0, false},
+	}
+	for _, tt := range tests {
+		n, window, ok := parseRateLimitHeader(tt.in)
+		if n != tt.n || window != tt.window || ok != tt.ok {
+			t.Errorf("parseRateLimitHeader(%q) = %d, %s, %v", tt.in, n, window, ok)
+		}
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	tests := []struct {
+		in   string
+		want time.Duration
+		ok   bool
+	}{
+		{"120", 2 * time.Minute, true},
+		{" 0 ", 0, true},
+		{"-5", 0, true},
+		{time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat), 0, true},
+		{"", 0, false},
+		{"soon", 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := parseRetryAfter(tt.in)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("parseRetryAfter(%q) = %s, %v, want %s, %v", tt.in, got, ok, tt.want, tt.ok)
+		}
+	}
+
+	// HTTP 日期精确到秒
+	got, ok := parseRetryAfter(time.Now().Add(time.Hour).UTC().Format(http.TimeFormat))
+	if !ok || got < 59*time.Minute || got > time.Hour {
+		t.Errorf("HTTP 日期 = %s, %v, want 约 1h", got, ok)
+	}
+}
@@ -0,0 +1,50 @@
+package registry
+
+import (
+	"io"
+	"log"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"ikl/pkg/tlsutil"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	ggcrregistry "github.com/google/go-containerregistry/pkg/registry"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+)
+
+// newTestClient 启动进程内的镜像仓库并返回访问它的客户端
+func newTestClient(t *testing.T) *Client {
+	t.Helper()
+	t.Setenv("DOCKER_CONFIG", t.TempDir())
+	t.Setenv("REGISTRY_AUTH_FILE", t.TempDir()+"/auth.json")
+
+	srv := httptest.NewServer(ggcrregistry.New(ggcrregistry.Logger(log.New(io.Discard, "", 0))))
+	t.Cleanup(srv.Close)
+
+	c, err := NewClient(strings.TrimPrefix(srv.URL, "http://"), "", "", tlsutil.Options{Insecure: true}, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+// writeTestImage 向仓库推送一个随机镜像
+func writeTestImage(t *testing.T, c *Client, repo, tag string) v1.Image {
+	t.Helper()
+	img, err := random.Image(1024, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ref, err := name.ParseReference(RefString(c.URL, repo, tag), getNameOptions(c.Insecure)...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := remote.Write(ref, img, c.GetOptions()...); err != nil {
+		t.Fatal(err)
+	}
+	return img
+}
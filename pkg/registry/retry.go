@@ -0,0 +1,171 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
+)
+
+// RetryPolicy 定义镜像拉取/推送失败后的重试策略
+type RetryPolicy struct {
+	Attempts   int           // 总尝试次数 (含首次)，1 表示不重试
+	Backoff    time.Duration // 首次重试前的等待时间，之后每次翻倍
+	MaxBackoff time.Duration // 单次等待时间上限
+	Jitter     float64       // 随机抖动比例 (0~1)，避免并发任务同时重试
+}
+
+// DefaultRetryPolicy 是未配置 retry 时使用的重试策略
+var DefaultRetryPolicy = RetryPolicy{
+	Attempts:   3,
+	Backoff:    2 * time.Second,
+	MaxBackoff: time.Minute,
+	Jitter:     0.2,
+}
+
+// Do 执行 fn，遇到可重试的错误时按指数退避重试，返回重试次数与最后一次的错误
+// op 用于日志描述当前操作，logf 为空时不输出日志
+func (p RetryPolicy) Do(ctx context.Context, op string, logf func(format string, a ...interface{}), fn func() error) (int, error) {
+	attempts := max(p.Attempts, 1)
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil || attempt >= attempts || !IsRetryable(err) {
+			return attempt - 1, err
+		}
+
+		wait := p.delay(attempt)
+		if after := retryAfter(err); after > wait {
+			wait = after
+		}
+		if logf != nil {
+			logf("🔁 %s 失败 (第 %d/%d 次): %v，%s 后重试\n", op, attempt, attempts, err, wait.Round(time.Millisecond))
+		}
+
+		select {
+		case <-ctx.Done():
+			return attempt - 1, err
+		case <-time.After(wait):
+		}
+	}
+}
+
+// delay 计算第 attempt 次失败后的等待时间: backoff * 2^(attempt-1)，附加 ±jitter 的随机抖动
+func (p RetryPolicy) delay(attempt int) time.Duration {
+	d := p.Backoff
+	for i := 1; i < attempt && (p.MaxBackoff == 0 || d < p.MaxBackoff); i++ {
+		d *= 2
+	}
+	if p.MaxBackoff > 0 && d > p.MaxBackoff {
+		d = p.MaxBackoff
+	}
+	if p.Jitter > 0 {
+		d += time.Duration((rand.Float64()*2 - 1) * p.Jitter * float64(d))
+	}
+	return max(d, 0)
+}
+
+// fatalErrorCodes 是 Registry 返回的不应重试的错误码
+var fatalErrorCodes = map[transport.ErrorCode]bool{
+	transport.ManifestUnknownErrorCode: true,
+	transport.NameUnknownErrorCode:     true,
+	transport.UnauthorizedErrorCode:    true,
+	transport.DeniedErrorCode:          true,
+}
+
+// IsRetryable 判断错误是否为临时故障
+// 可重试: HTTP 5xx、429、连接被重置、TLS 握手超时等网络超时
+// 不可重试: 401、403、404、manifest unknown 以及其他无法识别的错误
+func IsRetryable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+
+	var tErr *transport.Error
+	if errors.As(err, &tErr) {
+		for _, d := range tErr.Errors {
+			if fatalErrorCodes[d.Code] {
+				return false
+			}
+		}
+		switch {
+		case tErr.StatusCode == http.StatusUnauthorized,
+			tErr.StatusCode == http.StatusForbidden,
+			tErr.StatusCode == http.StatusNotFound:
+			return false
+		case tErr.StatusCode == http.StatusTooManyRequests,
+			tErr.StatusCode >= 500:
+			return true
+		}
+		return false
+	}
+
+	if errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.EPIPE) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+
+	// 部分错误经过多层包装后只保留了文本
+	msg := err.Error()
+	return strings.Contains(msg, "connection reset by peer") || strings.Contains(msg, "TLS handshake timeout")
+}
+
+// retryAfterHints 记录各仓库地址最近一次 429/503 响应中 Retry-After 指定的可重试时间
+var retryAfterHints sync.Map // host -> time.Time
+
+// retryAfter 返回错误对应的仓库要求的等待时间，没有 Retry-After 时返回 0
+func retryAfter(err error) time.Duration {
+	var tErr *transport.Error
+	if !errors.As(err, &tErr) || tErr.Request == nil || tErr.Request.URL == nil {
+		return 0
+	}
+	v, ok := retryAfterHints.Load(tErr.Request.URL.Host)
+	if !ok {
+		return 0
+	}
+	return time.Until(v.(time.Time))
+}
+
+// retryAfterTransport 记录 429/503 响应中的 Retry-After 头，供 RetryPolicy 计算等待时间
+type retryAfterTransport struct {
+	inner http.RoundTripper
+}
+
+func (t *retryAfterTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.inner.RoundTrip(req)
+	if err != nil {
+		return resp, err
+	}
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusServiceUnavailable {
+		if d, ok := parseRetryAfter(resp.Header.Get("Retry-After")); ok {
+			retryAfterHints.Store(req.URL.Host, time.Now().Add(d))
+		}
+	}
+	return resp, nil
+}
+
+// parseRetryAfter 解析 Retry-After 头，支持秒数与 HTTP 日期两种格式
+func parseRetryAfter(v string) (time.Duration, bool) {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(v); err == nil {
+		return time.Duration(max(secs, 0)) * time.Second, true
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		return max(time.Until(t), 0), true
+	}
+	return 0, false
+}
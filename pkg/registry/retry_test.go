@@ -0,0 +1,79 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"ikl/pkg/tlsutil"
+
+	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
+)
+
+func TestRetryPolicySingleRetryLayer(t *testing.T) {
+	var manifestRequests int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.Contains(r.URL.Path, "/manifests/") {
+			atomic.AddInt32(&manifestRequests, 1)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	t.Setenv("DOCKER_CONFIG", t.TempDir())
+	t.Setenv("REGISTRY_AUTH_FILE", t.TempDir()+"/auth.json")
+	c, err := NewClient(strings.TrimPrefix(srv.URL, "http://"), "", "", tlsutil.Options{Insecure: true}, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	policy := RetryPolicy{Attempts: 3, Backoff: time.Millisecond}
+	retries, err := policy.Do(context.Background(), "检查", nil, func() error {
+		_, err := c.GetDigest(context.Background(), "library/app", "v1")
+		return err
+	})
+	if err == nil {
+		t.Fatal("应返回 5xx 错误")
+	}
+	if retries != 2 {
+		t.Errorf("retries = %d, want 2", retries)
+	}
+	if n := atomic.LoadInt32(&manifestRequests); n != 3 {
+		t.Errorf("仓库收到 %d 次请求, want 3 (仅 RetryPolicy 一层重试)", n)
+	}
+}
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "nil", err: nil, want: false},
+		{name: "5xx", err: &transport.Error{StatusCode: http.StatusBadGateway}, want: true},
+		{name: "429", err: &transport.Error{StatusCode: http.StatusTooManyRequests}, want: true},
+		{name: "401", err: &transport.Error{StatusCode: http.StatusUnauthorized}, want: false},
+		{name: "404", err: &transport.Error{StatusCode: http.StatusNotFound}, want: false},
+		{name: "manifest unknown", err: &transport.Error{StatusCode: http.StatusInternalServerError, Errors: []transport.Diagnostic{{Code: transport.ManifestUnknownErrorCode}}}, want: false},
+		{name: "connection reset", err: fmt.Errorf("push: %w", syscall.ECONNRESET), want: true},
+		{name: "wrapped text", err: errors.New("read tcp: connection reset by peer"), want: true},
+		{name: "canceled", err: context.Canceled, want: false},
+		{name: "unknown", err: errors.New("invalid reference"), want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsRetryable(tt.err); got != tt.want {
+				t.Errorf("IsRetryable(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,39 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+	"ikl/pkg/signature"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+)
+
+// VerifySource 在推送前校验源镜像 digest 的 cosign 签名
+// 校验的是源仓库中 Tag 指向的原始 digest，按架构筛选不影响校验结果
+func VerifySource(ctx context.Context, src *Source, srcClient *Client, srcRepo string, verifier *signature.Verifier) error {
+	repo, err := name.NewRepository(srcClient.URL+"/"+srcRepo, getNameOptions(srcClient.Insecure)...)
+	if err != nil {
+		return fmt.Errorf("解析源镜像地址失败: %w", err)
+	}
+	opts := append(srcClient.GetOptions(), remote.WithContext(ctx))
+	if err := verifier.Verify(repo, src.SourceDigest, opts...); err != nil {
+		return fmt.Errorf("签名校验失败 (%s): %w", ShortDigest(src.SourceDigest), err)
+	}
+	return nil
+}
+
+// SignDigest 使用 signer 为目标仓库中的 digest 签名，签名以 cosign 的 sha256-<hex>.sig Tag 保存在同一仓库
+// 已存在同一私钥的签名时返回 false
+func SignDigest(ctx context.Context, dstClient *Client, dstRepo, digest string, signer *signature.Signer) (bool, error) {
+	repo, err := name.NewRepository(dstClient.URL+"/"+dstRepo, getNameOptions(dstClient.Insecure)...)
+	if err != nil {
+		return false, fmt.Errorf("解析目标镜像地址失败: %w", err)
+	}
+	opts := append(dstClient.GetOptions(), remote.WithContext(ctx))
+	signed, err := signer.Sign(repo, digest, opts...)
+	if err != nil {
+		return false, fmt.Errorf("为 %s 签名失败: %w", ShortDigest(digest), err)
+	}
+	return signed, nil
+}
@@ -0,0 +1,43 @@
+package registry
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"sync/atomic"
+)
+
+// uploadCounter 统计实际上传到目标仓库的 blob 字节数
+// 目标仓库已存在 (HEAD 命中) 或跨仓库挂载的 blob 不会发送请求体，因此不计入
+type uploadCounter struct {
+	inner http.RoundTripper
+	bytes atomic.Int64
+}
+
+func (t *uploadCounter) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body == nil || (req.Method != http.MethodPatch && req.Method != http.MethodPut) || !strings.Contains(req.URL.Path, "/blobs/uploads/") {
+		return t.inner.RoundTrip(req)
+	}
+
+	body := &countingReader{ReadCloser: req.Body}
+	req = req.Clone(req.Context())
+	req.Body = body
+	resp, err := t.inner.RoundTrip(req)
+	// 仅计入仓库接受的上传，被拒绝的请求会由 RetryPolicy 整体重试
+	if err == nil && resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		t.bytes.Add(body.n.Load())
+	}
+	return resp, err
+}
+
+// countingReader 记录已读取的字节数
+type countingReader struct {
+	io.ReadCloser
+	n atomic.Int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	r.n.Add(int64(n))
+	return n, err
+}
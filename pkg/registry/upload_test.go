@@ -0,0 +1,62 @@
+package registry
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+)
+
+// TestPushImageBytesTransferred 目标仓库已存在的层不计入传输量，强制重新推送时没有需要上传的 blob
+func TestPushImageBytesTransferred(t *testing.T) {
+	ctx := context.Background()
+	c := newTestClient(t)
+	dst := newTestClient(t)
+	img := writeTestImage(t, c, "library/app", "v1")
+
+	src, err := ResolveSource(ctx, c, "library/app", "v1", nil, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	blobs, err := src.Blobs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var total int64
+	for _, b := range blobs {
+		total += b.Size
+	}
+
+	layers, err := img.Layers()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dstRepo, err := name.NewRepository(dst.URL+"/mirror/app", getNameOptions(dst.Insecure)...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := remote.WriteLayer(dstRepo, layers[0], dst.GetOptions()...); err != nil {
+		t.Fatal(err)
+	}
+	existing, err := layers[0].Size()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := PushImage(ctx, src, dst, "mirror/app", "v1", nil, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.BytesTransferred != total-existing {
+		t.Errorf("BytesTransferred = %d, want %d", result.BytesTransferred, total-existing)
+	}
+
+	result, err = PushImage(ctx, src, dst, "mirror/app", "v1", nil, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.BytesTransferred != 0 {
+		t.Errorf("强制重新推送 BytesTransferred = %d, want 0", result.BytesTransferred)
+	}
+}
@@ -0,0 +1,234 @@
+package report
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 报告中单个条目的结果
+const (
+	StatusSuccess  = "success"
+	StatusUpToDate = "up-to-date"
+	StatusSkipped  = "skipped"
+	StatusFailed   = "failed"
+)
+
+// 报告文件格式
+const (
+	FormatJSON  = "json"
+	FormatJUnit = "junit"
+)
+
+// Entry 记录单个 源镜像 -> 目标镜像 的迁移结果
+type Entry struct {
+	Registry          string   `json:"registry"` // 目标仓库地址
+	Source            string   `json:"source"`
+	Destination       string   `json:"destination"`
+	SourceDigest      string   `json:"sourceDigest,omitempty"`
+	DestinationDigest string   `json:"destinationDigest,omitempty"`
+	Platforms         []string `json:"platforms,omitempty"` // 架构筛选后保留的平台
+	BytesTransferred  int64    `json:"bytesTransferred"`    // 实际上传的层与配置字节数，目标仓库已存在的 blob 不计入
+	DurationSeconds   float64  `json:"durationSeconds"`
+	Retries           int      `json:"retries"`
+	Artifacts         int      `json:"artifacts,omitempty"` // 复制的签名、证明与 SBOM 数量
+	Signed            bool     `json:"signed,omitempty"`    // 是否已在目标仓库签名
+	Status            string   `json:"status"`
+	Error             string   `json:"error,omitempty"`
+}
+
+// Summary 汇总各状态的条目数
+type Summary struct {
+	Total    int `json:"total"`
+	Success  int `json:"success"`
+	UpToDate int `json:"upToDate"`
+	Skipped  int `json:"skipped"`
+	Failed   int `json:"failed"`
+}
+
+// Report 是一次迁移的机器可读报告，可并发添加条目
+type Report struct {
+	StartedAt  time.Time `json:"startedAt"`
+	FinishedAt time.Time `json:"finishedAt"`
+	Summary    Summary   `json:"summary"`
+	Entries    []Entry   `json:"entries"`
+
+	mu sync.Mutex
+}
+
+// New 创建报告并记录开始时间
+func New() *Report {
+	return &Report{
+		StartedAt: time.Now(),
+		Entries:   []Entry{},
+	}
+}
+
+// Add 添加一条结果，nil 报告不记录任何内容
+func (r *Report) Add(e Entry) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.Entries = append(r.Entries, e)
+}
+
+// FormatFromPath 根据文件扩展名推断报告格式，.xml 为 JUnit，其余为 JSON
+func FormatFromPath(path string) string {
+	if strings.EqualFold(filepath.Ext(path), ".xml") {
+		return FormatJUnit
+	}
+	return FormatJSON
+}
+
+// Write 汇总结果并按 format 写入 path
+func (r *Report) Write(path, format string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.FinishedAt = time.Now()
+	// worker 并发完成的顺序不固定，按目标仓库、源、目标排序便于比较
+	sort.SliceStable(r.Entries, func(i, j int) bool {
+		a, b := r.Entries[i], r.Entries[j]
+		if a.Registry != b.Registry {
+			return a.Registry < b.Registry
+		}
+		if a.Source != b.Source {
+			return a.Source < b.Source
+		}
+		return a.Destination < b.Destination
+	})
+	r.Summary = Summary{Total: len(r.Entries)}
+	for _, e := range r.Entries {
+		switch e.Status {
+		case StatusSuccess:
+			r.Summary.Success++
+		case StatusUpToDate:
+			r.Summary.UpToDate++
+		case StatusSkipped:
+			r.Summary.Skipped++
+		case StatusFailed:
+			r.Summary.Failed++
+		}
+	}
+
+	var data []byte
+	var err error
+	switch format {
+	case FormatJSON:
+		data, err = json.MarshalIndent(r, "", "  ")
+	case FormatJUnit:
+		data, err = r.junit()
+	default:
+		return fmt.Errorf("不支持的报告格式 %q (支持: json, junit)", format)
+	}
+	if err != nil {
+		return fmt.Errorf("生成报告失败: %w", err)
+	}
+
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("写入报告 %s 失败: %w", path, err)
+	}
+	return nil
+}
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Skipped  int              `xml:"skipped,attr"`
+	Time     string           `xml:"time,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Skipped   int             `xml:"skipped,attr"`
+	Time      string          `xml:"time,attr"`
+	Timestamp string          `xml:"timestamp,attr"`
+	Cases     []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	Classname string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitMessage `xml:"failure,omitempty"`
+	Skipped   *junitMessage `xml:"skipped,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// junit 生成 JUnit XML，每个目标仓库对应一个 testsuite，每个 镜像:Tag 对应一个 testcase
+func (r *Report) junit() ([]byte, error) {
+	seconds := func(s float64) string { return fmt.Sprintf("%.3f", s) }
+
+	root := junitTestSuites{
+		Name:     "ikl migrate",
+		Tests:    r.Summary.Total,
+		Failures: r.Summary.Failed,
+		Skipped:  r.Summary.Skipped,
+		Time:     seconds(r.FinishedAt.Sub(r.StartedAt).Seconds()),
+	}
+
+	suites := make(map[string]*junitTestSuite)
+	var order []string
+	for _, e := range r.Entries {
+		suite, ok := suites[e.Registry]
+		if !ok {
+			suite = &junitTestSuite{Name: e.Registry, Timestamp: r.StartedAt.UTC().Format(time.RFC3339)}
+			suites[e.Registry] = suite
+			order = append(order, e.Registry)
+		}
+
+		tc := junitTestCase{
+			Name:      e.Source + " -> " + e.Destination,
+			Classname: e.Registry,
+			Time:      seconds(e.DurationSeconds),
+			SystemOut: fmt.Sprintf("status: %s\nsource digest: %s\ndestination digest: %s\nplatforms: %s\nbytes transferred: %d\nretries: %d\nartifacts: %d\nsigned: %v",
+				e.Status, e.SourceDigest, e.DestinationDigest, strings.Join(e.Platforms, ", "), e.BytesTransferred, e.Retries, e.Artifacts, e.Signed),
+		}
+		switch e.Status {
+		case StatusFailed:
+			tc.Failure = &junitMessage{Message: e.Error, Text: e.Error}
+			suite.Failures++
+		case StatusSkipped:
+			tc.Skipped = &junitMessage{Message: "已迁移 (断点续传跳过)"}
+			suite.Skipped++
+		}
+		suite.Tests++
+		suite.Cases = append(suite.Cases, tc)
+	}
+
+	for _, name := range order {
+		suite := suites[name]
+		var total float64
+		for _, e := range r.Entries {
+			if e.Registry == name {
+				total += e.DurationSeconds
+			}
+		}
+		suite.Time = seconds(total)
+		root.Suites = append(root.Suites, *suite)
+	}
+
+	data, err := xml.MarshalIndent(root, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), data...), nil
+}
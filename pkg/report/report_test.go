@@ -0,0 +1,163 @@
+package report
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// testReport 返回包含两个目标仓库、各种状态的报告，条目故意以乱序添加
+func testReport() *Report {
+	r := New()
+	r.Add(Entry{Registry: "b.io", Source: "docker.io/library/nginx:1", Destination: "b.io/library/nginx:1", Status: StatusSkipped})
+	r.Add(Entry{
+		Registry:        "a.io",
+		Source:          "docker.io/library/redis:7",
+		Destination:     "a.io/library/redis:7",
+		Status:          StatusFailed,
+		Error:           "推送到目标仓库失败",
+		DurationSeconds: 1.5,
+		Retries:         2,
+		SourceDigest:    "sha256:1",
+	})
+	r.Add(Entry{
+		Registry:          "a.io",
+		Source:            "docker.io/library/nginx:1",
+		Destination:       "a.io/library/nginx:1",
+		Status:            StatusSuccess,
+		SourceDigest:      "sha256:2",
+		DestinationDigest: "sha256:3",
+		Platforms:         []string{"linux/amd64", "linux/arm64"},
+		BytesTransferred:  1024,
+		DurationSeconds:   2.5,
+		Artifacts:         1,
+		Signed:            true,
+	})
+	r.Add(Entry{Registry: "b.io", Source: "docker.io/library/nginx:2", Destination: "b.io/library/nginx:2", Status: StatusUpToDate})
+	return r
+}
+
+func TestWriteJSON(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "report.json")
+	if err := testReport().Write(path, FormatFromPath(path)); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got struct {
+		StartedAt  string                   `json:"startedAt"`
+		FinishedAt string                   `json:"finishedAt"`
+		Summary    map[string]int           `json:"summary"`
+		Entries    []map[string]interface{} `json:"entries"`
+	}
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.StartedAt == "" || got.FinishedAt == "" {
+		t.Errorf("缺少开始/结束时间: %s", data)
+	}
+	want := map[string]int{"total": 4, "success": 1, "upToDate": 1, "skipped": 1, "failed": 1}
+	for k, v := range want {
+		if got.Summary[k] != v {
+			t.Errorf("summary.%s = %d, want %d", k, got.Summary[k], v)
+		}
+	}
+
+	var order []string
+	for _, e := range got.Entries {
+		order = append(order, e["destination"].(string))
+	}
+	if strings.Join(order, ",") != "a.io/library/nginx:1,a.io/library/redis:7,b.io/library/nginx:1,b.io/library/nginx:2" {
+		t.Errorf("条目应按目标仓库、源、目标排序: %v", order)
+	}
+
+	success := got.Entries[0]
+	for key, want := range map[string]interface{}{
+		"registry":          "a.io",
+		"source":            "docker.io/library/nginx:1",
+		"sourceDigest":      "sha256:2",
+		"destinationDigest": "sha256:3",
+		"bytesTransferred":  float64(1024),
+		"durationSeconds":   2.5,
+		"retries":           float64(0),
+		"artifacts":         float64(1),
+		"signed":            true,
+		"status":            StatusSuccess,
+	} {
+		if success[key] != want {
+			t.Errorf("%s = %v, want %v", key, success[key], want)
+		}
+	}
+	if _, ok := success["error"]; ok {
+		t.Errorf("成功条目不应包含 error 字段")
+	}
+	if failed := got.Entries[1]; failed["error"] != "推送到目标仓库失败" || failed["retries"] != float64(2) {
+		t.Errorf("失败条目 = %v", failed)
+	}
+}
+
+func TestWriteJUnit(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "junit.xml")
+	if FormatFromPath(path) != FormatJUnit {
+		t.Fatalf("FormatFromPath(%s) = %s", path, FormatFromPath(path))
+	}
+	if err := testReport().Write(path, FormatJUnit); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(data), xml.Header) {
+		t.Errorf("缺少 XML 声明")
+	}
+
+	var got junitTestSuites
+	if err := xml.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Tests != 4 || got.Failures != 1 || got.Skipped != 1 {
+		t.Errorf("testsuites tests/failures/skipped = %d/%d/%d, want 4/1/1", got.Tests, got.Failures, got.Skipped)
+	}
+	if len(got.Suites) != 2 || got.Suites[0].Name != "a.io" || got.Suites[1].Name != "b.io" {
+		t.Fatalf("testsuite = %+v", got.Suites)
+	}
+
+	a := got.Suites[0]
+	if a.Tests != 2 || a.Failures != 1 || a.Skipped != 0 || a.Time != "4.000" {
+		t.Errorf("a.io tests/failures/skipped/time = %d/%d/%d/%s", a.Tests, a.Failures, a.Skipped, a.Time)
+	}
+	success, failed := a.Cases[0], a.Cases[1]
+	if success.Name != "docker.io/library/nginx:1 -> a.io/library/nginx:1" || success.Classname != "a.io" || success.Time != "2.500" {
+		t.Errorf("testcase = %+v", success)
+	}
+	if success.Failure != nil || !strings.Contains(success.SystemOut, "bytes transferred: 1024") {
+		t.Errorf("成功条目 = %+v", success)
+	}
+	if failed.Failure == nil || failed.Failure.Message != "推送到目标仓库失败" {
+		t.Errorf("失败条目 failure = %+v", failed.Failure)
+	}
+
+	b := got.Suites[1]
+	if b.Tests != 2 || b.Skipped != 1 || b.Cases[0].Skipped == nil || b.Cases[1].Skipped != nil {
+		t.Errorf("b.io = %+v", b)
+	}
+}
+
+func TestWriteUnknownFormat(t *testing.T) {
+	err := testReport().Write(filepath.Join(t.TempDir(), "report.txt"), "csv")
+	if err == nil || !strings.Contains(err.Error(), "不支持的报告格式") {
+		t.Errorf("err = %v", err)
+	}
+}
+
+func TestNilReportAdd(t *testing.T) {
+	var r *Report
+	r.Add(Entry{Status: StatusSuccess})
+}
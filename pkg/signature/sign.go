@@ -0,0 +1,221 @@
+package signature
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
+	"github.com/google/go-containerregistry/pkg/v1/static"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+	"golang.org/x/crypto/nacl/secretbox"
+	"golang.org/x/crypto/scrypt"
+)
+
+// Signer 使用本地私钥生成 cosign 兼容的签名
+type Signer struct {
+	key crypto.Signer
+}
+
+// NewSigner 读取 PEM 格式的私钥 (PKCS#8、EC、PKCS#1 RSA，或 cosign generate-key-pair 生成的加密私钥)
+// password 为加密私钥的密码，私钥未加密时忽略
+func NewSigner(path string, password []byte) (*Signer, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取私钥 %s 失败: %w", path, err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("私钥 %s 中没有有效的 PEM 数据", path)
+	}
+
+	var key interface{}
+	switch block.Type {
+	case "PRIVATE KEY":
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		key, err = x509.ParseECPrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "ENCRYPTED SIGSTORE PRIVATE KEY", "ENCRYPTED COSIGN PRIVATE KEY":
+		var der []byte
+		if der, err = decryptCosignKey(block.Bytes, password); err != nil {
+			return nil, fmt.Errorf("解密私钥 %s 失败: %w", path, err)
+		}
+		key, err = x509.ParsePKCS8PrivateKey(der)
+	case "ENCRYPTED PRIVATE KEY":
+		return nil, fmt.Errorf("私钥 %s 是 openssl 加密的 PKCS#8 私钥，请使用 cosign generate-key-pair 生成的私钥或未加密的 PEM 私钥", path)
+	default:
+		return nil, fmt.Errorf("私钥 %s 的 PEM 类型 %q 不受支持", path, block.Type)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("解析私钥 %s 失败: %w", path, err)
+	}
+
+	switch k := key.(type) {
+	case *ecdsa.PrivateKey, *rsa.PrivateKey, ed25519.PrivateKey:
+		return &Signer{key: k.(crypto.Signer)}, nil
+	default:
+		return nil, fmt.Errorf("不支持的私钥类型 %T", key)
+	}
+}
+
+// encryptedKey 是 cosign 加密私钥的 PEM 内容：scrypt 派生密钥，nacl/secretbox 加密 PKCS#8 私钥
+type encryptedKey struct {
+	KDF struct {
+		Name   string `json:"name"`
+		Params struct {
+			N int `json:"N"`
+			R int `json:"r"`
+			P int `json:"p"`
+		} `json:"params"`
+		Salt []byte `json:"salt"`
+	} `json:"kdf"`
+	Cipher struct {
+		Name  string `json:"name"`
+		Nonce []byte `json:"nonce"`
+	} `json:"cipher"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// maxScryptN 限制 scrypt 的 N 参数，避免私钥文件中的异常参数占用过多内存 (cosign 默认为 32768)
+const maxScryptN = 1 << 20
+
+// decryptCosignKey 解密 cosign 加密私钥，返回 PKCS#8 DER
+func decryptCosignKey(data, password []byte) ([]byte, error) {
+	var k encryptedKey
+	if err := json.Unmarshal(data, &k); err != nil {
+		return nil, fmt.Errorf("解析加密私钥失败: %w", err)
+	}
+	if k.KDF.Name != "scrypt" {
+		return nil, fmt.Errorf("不支持的密钥派生算法 %q", k.KDF.Name)
+	}
+	if k.Cipher.Name != "nacl/secretbox" {
+		return nil, fmt.Errorf("不支持的加密算法 %q", k.Cipher.Name)
+	}
+	if k.KDF.Params.N > maxScryptN {
+		return nil, fmt.Errorf("scrypt 参数 N=%d 过大", k.KDF.Params.N)
+	}
+	if len(k.Cipher.Nonce) != 24 {
+		return nil, fmt.Errorf("nonce 长度应为 24 字节，实际为 %d", len(k.Cipher.Nonce))
+	}
+
+	derived, err := scrypt.Key(password, k.KDF.Salt, k.KDF.Params.N, k.KDF.Params.R, k.KDF.Params.P, 32)
+	if err != nil {
+		return nil, fmt.Errorf("派生密钥失败: %w", err)
+	}
+	var secret [32]byte
+	var nonce [24]byte
+	copy(secret[:], derived)
+	copy(nonce[:], k.Cipher.Nonce)
+	der, ok := secretbox.Open(nil, k.Ciphertext, &nonce, &secret)
+	if !ok {
+		return nil, errors.New("密码错误或私钥已损坏")
+	}
+	return der, nil
+}
+
+// Sign 为 repo 中的 digest 生成签名，并追加到 sha256-<hex>.sig Tag 中已有的签名之后
+// 已存在同一私钥对同一载荷的签名时不重复签名，返回 false
+func (s *Signer) Sign(repo name.Repository, digest string, opts ...remote.Option) (bool, error) {
+	var p Payload
+	p.Critical.Identity.DockerReference = repo.Name()
+	p.Critical.Image.DockerManifestDigest = digest
+	p.Critical.Type = payloadType
+	payload, err := json.Marshal(p)
+	if err != nil {
+		return false, err
+	}
+
+	tag := repo.Tag(SignatureTag(digest))
+	base, err := remote.Image(tag, opts...)
+	var terr *transport.Error
+	switch {
+	case errors.As(err, &terr) && terr.StatusCode == http.StatusNotFound:
+		base = mutate.ConfigMediaType(mutate.MediaType(empty.Image, types.OCIManifestSchema1), types.OCIConfigJSON)
+	case err != nil:
+		return false, fmt.Errorf("获取已有签名 %s 失败: %w", tag.TagStr(), err)
+	default:
+		signed, err := s.signedBy(base, payload)
+		if err != nil {
+			return false, fmt.Errorf("读取已有签名 %s 失败: %w", tag.TagStr(), err)
+		}
+		if signed {
+			return false, nil
+		}
+	}
+
+	sig, err := s.sign(payload)
+	if err != nil {
+		return false, fmt.Errorf("签名失败: %w", err)
+	}
+	img, err := mutate.Append(base, mutate.Addendum{
+		Layer:       static.NewLayer(payload, SimpleSigningMediaType),
+		Annotations: map[string]string{AnnotationSignature: base64.StdEncoding.EncodeToString(sig)},
+	})
+	if err != nil {
+		return false, err
+	}
+	if err := remote.Write(tag, img, opts...); err != nil {
+		return false, fmt.Errorf("推送签名 %s 失败: %w", tag.TagStr(), err)
+	}
+	return true, nil
+}
+
+// sign 按 cosign 的约定签名：ECDSA/RSA 对载荷的 SHA-256 摘要签名，Ed25519 对原始载荷签名
+func (s *Signer) sign(payload []byte) ([]byte, error) {
+	if _, ok := s.key.(ed25519.PrivateKey); ok {
+		return s.key.Sign(rand.Reader, payload, crypto.Hash(0))
+	}
+	digest := sha256.Sum256(payload)
+	return s.key.Sign(rand.Reader, digest[:], crypto.SHA256)
+}
+
+// signedBy 判断签名镜像中是否已有该私钥对 payload 的有效签名
+func (s *Signer) signedBy(img v1.Image, payload []byte) (bool, error) {
+	manifest, err := img.Manifest()
+	if err != nil {
+		return false, err
+	}
+	for _, desc := range manifest.Layers {
+		sig, err := base64.StdEncoding.DecodeString(desc.Annotations[AnnotationSignature])
+		if err != nil || len(sig) == 0 {
+			continue
+		}
+		layer, err := img.LayerByDigest(desc.Digest)
+		if err != nil {
+			return false, err
+		}
+		rc, err := layer.Compressed()
+		if err != nil {
+			return false, err
+		}
+		existing, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return false, err
+		}
+		if bytes.Equal(existing, payload) && verifySignature(s.key.Public(), payload, sig) == nil {
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,184 @@
+package signature
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"io"
+	"log"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"golang.org/x/crypto/nacl/secretbox"
+	"golang.org/x/crypto/scrypt"
+)
+
+// newTestImage 启动进程内的镜像仓库并推送一个随机镜像，返回仓库与镜像 digest
+func newTestImage(t *testing.T) (name.Repository, string) {
+	t.Helper()
+	srv := httptest.NewServer(registry.New(registry.Logger(log.New(io.Discard, "", 0))))
+	t.Cleanup(srv.Close)
+
+	repo, err := name.NewRepository(strings.TrimPrefix(srv.URL, "http://")+"/library/app", name.Insecure)
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, err := random.Image(256, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := remote.Write(repo.Tag("v1"), img); err != nil {
+		t.Fatal(err)
+	}
+	digest, err := img.Digest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return repo, digest.String()
+}
+
+// writePEM 将 DER 数据以 PEM 格式写入临时目录，返回文件路径
+func writePEM(t *testing.T, typ string, der []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), strings.ToLower(strings.ReplaceAll(typ, " ", "-"))+".pem")
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: typ, Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// writePublicKey 写入 PEM 公钥，返回文件路径
+func writePublicKey(t *testing.T, pub interface{}) string {
+	t.Helper()
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return writePEM(t, "PUBLIC KEY", der)
+}
+
+// encryptCosignKey 按 cosign generate-key-pair 的格式加密 PKCS#8 私钥，N 取较小值以加快测试
+func encryptCosignKey(t *testing.T, der, password []byte) []byte {
+	t.Helper()
+	var k encryptedKey
+	k.KDF.Name = "scrypt"
+	k.KDF.Params.N, k.KDF.Params.R, k.KDF.Params.P = 1<<10, 8, 1
+	k.KDF.Salt = make([]byte, 32)
+	k.Cipher.Name = "nacl/secretbox"
+	k.Cipher.Nonce = make([]byte, 24)
+	rand.Read(k.KDF.Salt)
+	rand.Read(k.Cipher.Nonce)
+
+	derived, err := scrypt.Key(password, k.KDF.Salt, k.KDF.Params.N, k.KDF.Params.R, k.KDF.Params.P, 32)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var secret [32]byte
+	var nonce [24]byte
+	copy(secret[:], derived)
+	copy(nonce[:], k.Cipher.Nonce)
+	k.Ciphertext = secretbox.Seal(nil, der, &nonce, &secret)
+
+	data, err := json.Marshal(k)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestNewSignerEncryptedKey(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	encrypted := encryptCosignKey(t, der, []byte("s3cret"))
+
+	for _, typ := range []string{"ENCRYPTED SIGSTORE PRIVATE KEY", "ENCRYPTED COSIGN PRIVATE KEY"} {
+		path := writePEM(t, typ, encrypted)
+		if _, err := NewSigner(path, []byte("s3cret")); err != nil {
+			t.Errorf("%s: %v", typ, err)
+		}
+		if _, err := NewSigner(path, []byte("wrong")); err == nil || !strings.Contains(err.Error(), "密码错误") {
+			t.Errorf("%s 使用错误的密码: err = %v", typ, err)
+		}
+	}
+
+	if _, err := NewSigner(writePEM(t, "ENCRYPTED PRIVATE KEY", der), nil); err == nil || !strings.Contains(err.Error(), "openssl 加密") {
+		t.Errorf("openssl 加密私钥: err = %v", err)
+	}
+}
+
+// TestSignVerifyRoundTrip 签名后使用对应公钥校验，并确认同一私钥不会重复签名
+func TestSignVerifyRoundTrip(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecDER, err := x509.MarshalPKCS8PrivateKey(ecKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edPub, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edDER, err := x509.MarshalPKCS8PrivateKey(edKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		key      string
+		password []byte
+		pub      interface{}
+	}{
+		{"cosign 加密私钥", writePEM(t, "ENCRYPTED SIGSTORE PRIVATE KEY", encryptCosignKey(t, ecDER, []byte("s3cret"))), []byte("s3cret"), &ecKey.PublicKey},
+		{"Ed25519 PKCS#8 私钥", writePEM(t, "PRIVATE KEY", edDER), nil, edPub},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, digest := newTestImage(t)
+			signer, err := NewSigner(tt.key, tt.password)
+			if err != nil {
+				t.Fatal(err)
+			}
+			verifier, err := NewVerifier(Policy{Key: writePublicKey(t, tt.pub)})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if err := verifier.Verify(repo, digest); !errors.Is(err, ErrNoSignature) {
+				t.Fatalf("签名前 Verify err = %v, want ErrNoSignature", err)
+			}
+			for i, want := range []bool{true, false} {
+				created, err := signer.Sign(repo, digest)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if created != want {
+					t.Errorf("第 %d 次签名 created = %v, want %v", i+1, created, want)
+				}
+			}
+			if err := verifier.Verify(repo, digest); err != nil {
+				t.Errorf("Verify: %v", err)
+			}
+		})
+	}
+}
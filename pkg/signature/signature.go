@@ -0,0 +1,91 @@
+package signature
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// cosign 签名格式：签名保存在 sha256-<hex>.sig Tag 的镜像中，每个层是一份 simple signing 载荷，
+// 签名 (base64) 与 keyless 证书通过层注解保存
+const (
+	SimpleSigningMediaType = "application/vnd.dev.cosign.simplesigning.v1+json"
+	AnnotationSignature    = "dev.cosignproject.cosign/signature"
+	AnnotationCertificate  = "dev.sigstore.cosign/certificate"
+	AnnotationChain        = "dev.sigstore.cosign/chain"
+	AnnotationBundle       = "dev.sigstore.cosign/bundle"
+
+	payloadType = "cosign container image signature"
+)
+
+// Payload 是 cosign simple signing 载荷，签名针对其原始 JSON 字节
+type Payload struct {
+	Critical struct {
+		Identity struct {
+			DockerReference string `json:"docker-reference"`
+		} `json:"identity"`
+		Image struct {
+			DockerManifestDigest string `json:"docker-manifest-digest"`
+		} `json:"image"`
+		Type string `json:"type"`
+	} `json:"critical"`
+	Optional map[string]interface{} `json:"optional"`
+}
+
+// SignatureTag 返回 digest 对应的 cosign 签名 Tag，如 sha256-<hex>.sig
+func SignatureTag(digest string) string {
+	return strings.Replace(digest, ":", "-", 1) + ".sig"
+}
+
+// loadPublicKey 读取 PEM 格式的公钥，支持 ECDSA、RSA 与 Ed25519
+func loadPublicKey(path string) (crypto.PublicKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取公钥 %s 失败: %w", path, err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("公钥 %s 中没有有效的 PEM 数据", path)
+	}
+	if block.Type == "CERTIFICATE" {
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("解析证书 %s 失败: %w", path, err)
+		}
+		return cert.PublicKey, nil
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("解析公钥 %s 失败: %w", path, err)
+	}
+	return key, nil
+}
+
+// verifySignature 按 cosign 的约定校验签名：ECDSA/RSA 对载荷的 SHA-256 摘要签名，Ed25519 对原始载荷签名
+func verifySignature(pub crypto.PublicKey, payload, sig []byte) error {
+	digest := sha256.Sum256(payload)
+	switch key := pub.(type) {
+	case *ecdsa.PublicKey:
+		if !ecdsa.VerifyASN1(key, digest[:], sig) {
+			return fmt.Errorf("ECDSA 签名无效")
+		}
+	case *rsa.PublicKey:
+		if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], sig); err != nil {
+			return fmt.Errorf("RSA 签名无效: %w", err)
+		}
+	case ed25519.PublicKey:
+		if !ed25519.Verify(key, payload, sig) {
+			return fmt.Errorf("Ed25519 签名无效")
+		}
+	default:
+		return fmt.Errorf("不支持的公钥类型 %T", pub)
+	}
+	return nil
+}
@@ -0,0 +1,358 @@
+package signature
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
+)
+
+// Policy 定义源镜像的 cosign 签名校验规则，key 与 keyless (identity/identity_regexp) 二选一
+// 文件路径为相对路径时以配置文件所在目录为基准
+type Policy struct {
+	Key string `yaml:"key"` // cosign 公钥 (PEM)，如 cosign generate-key-pair 生成的 cosign.pub
+
+	// keyless 签名：校验 Fulcio 签发的证书链、签名身份与 OIDC 签发者，并通过 Rekor 凭证确认签名时证书有效
+	Identity       string `yaml:"identity"`        // 证书中的签名身份 (邮箱或 URI)，精确匹配
+	IdentityRegexp string `yaml:"identity_regexp"` // 签名身份正则
+	Issuer         string `yaml:"issuer"`          // OIDC 签发者，如 https://token.actions.githubusercontent.com
+	Roots          string `yaml:"roots"`           // Fulcio 根证书与中间证书 (PEM)
+	RekorKey       string `yaml:"rekor_key"`       // Rekor 公钥 (PEM)
+}
+
+// Keyless 判断是否为 keyless 规则
+func (p Policy) Keyless() bool {
+	return p.Identity != "" || p.IdentityRegexp != ""
+}
+
+// Validate 检查规则的字段组合
+func (p Policy) Validate() error {
+	switch {
+	case p.Key != "" && p.Keyless():
+		return fmt.Errorf("key 与 identity/identity_regexp 只能配置其中一个")
+	case p.Key == "" && !p.Keyless():
+		return fmt.Errorf("需要配置 key 或 identity/identity_regexp")
+	case p.Identity != "" && p.IdentityRegexp != "":
+		return fmt.Errorf("identity 与 identity_regexp 只能配置其中一个")
+	case p.Keyless() && (p.Issuer == "" || p.Roots == "" || p.RekorKey == ""):
+		return fmt.Errorf("keyless 校验需要同时配置 issuer、roots 与 rekor_key")
+	}
+	if p.IdentityRegexp != "" {
+		if _, err := regexp.Compile(p.IdentityRegexp); err != nil {
+			return fmt.Errorf("identity_regexp 正则无效: %w", err)
+		}
+	}
+	return nil
+}
+
+// Fulcio 证书中记录 OIDC 签发者的扩展
+var (
+	oidIssuerV1 = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 1}
+	oidIssuerV2 = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 8}
+)
+
+// ErrNoSignature 表示源仓库中没有该 digest 的 cosign 签名
+var ErrNoSignature = errors.New("未找到 cosign 签名")
+
+// Verifier 是加载了公钥与证书的 Policy
+type Verifier struct {
+	policy        Policy
+	key           crypto.PublicKey
+	identity      *regexp.Regexp
+	roots         *x509.CertPool
+	intermediates *x509.CertPool
+	rekorKey      crypto.PublicKey
+}
+
+// NewVerifier 校验 Policy 并加载其中的公钥与证书
+func NewVerifier(p Policy) (*Verifier, error) {
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	v := &Verifier{policy: p}
+
+	var err error
+	if p.Key != "" {
+		if v.key, err = loadPublicKey(p.Key); err != nil {
+			return nil, err
+		}
+		return v, nil
+	}
+
+	if p.IdentityRegexp != "" {
+		v.identity = regexp.MustCompile(p.IdentityRegexp)
+	}
+	if v.roots, v.intermediates, err = loadCertPools(p.Roots); err != nil {
+		return nil, err
+	}
+	if v.rekorKey, err = loadPublicKey(p.RekorKey); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// Verify 校验 repo 中 digest 的 cosign 签名，任意一个签名通过即视为通过
+func (v *Verifier) Verify(repo name.Repository, digest string, opts ...remote.Option) error {
+	tag := SignatureTag(digest)
+	img, err := remote.Image(repo.Tag(tag), opts...)
+	if err != nil {
+		var terr *transport.Error
+		if errors.As(err, &terr) && terr.StatusCode == http.StatusNotFound {
+			return fmt.Errorf("%w (%s)", ErrNoSignature, tag)
+		}
+		return fmt.Errorf("获取签名 %s 失败: %w", tag, err)
+	}
+	manifest, err := img.Manifest()
+	if err != nil {
+		return fmt.Errorf("解析签名 %s 失败: %w", tag, err)
+	}
+
+	var errs []string
+	for i, layer := range manifest.Layers {
+		if err := v.verifyLayer(img, layer, digest); err != nil {
+			errs = append(errs, fmt.Sprintf("签名 %d: %v", i+1, err))
+			continue
+		}
+		return nil
+	}
+	if len(errs) == 0 {
+		return fmt.Errorf("%w (%s 中没有签名)", ErrNoSignature, tag)
+	}
+	return fmt.Errorf("没有通过校验的签名: %s", strings.Join(errs, "; "))
+}
+
+// verifyLayer 校验单个签名层：签名有效、载荷指向 digest，keyless 时还需校验证书与 Rekor 凭证
+func (v *Verifier) verifyLayer(img v1.Image, desc v1.Descriptor, digest string) error {
+	sig, err := base64.StdEncoding.DecodeString(desc.Annotations[AnnotationSignature])
+	if err != nil || len(sig) == 0 {
+		return fmt.Errorf("缺少有效的 %s 注解", AnnotationSignature)
+	}
+
+	layer, err := img.LayerByDigest(desc.Digest)
+	if err != nil {
+		return err
+	}
+	rc, err := layer.Compressed()
+	if err != nil {
+		return err
+	}
+	payload, err := io.ReadAll(rc)
+	rc.Close()
+	if err != nil {
+		return fmt.Errorf("读取签名载荷失败: %w", err)
+	}
+
+	key := v.key
+	if v.policy.Keyless() {
+		if key, err = v.verifyCertificate(desc.Annotations, payload, sig); err != nil {
+			return err
+		}
+	}
+	if err := verifySignature(key, payload, sig); err != nil {
+		return err
+	}
+
+	var p Payload
+	if err := json.Unmarshal(payload, &p); err != nil {
+		return fmt.Errorf("解析签名载荷失败: %w", err)
+	}
+	if p.Critical.Type != payloadType {
+		return fmt.Errorf("未知的签名载荷类型 %q", p.Critical.Type)
+	}
+	if p.Critical.Image.DockerManifestDigest != digest {
+		return fmt.Errorf("签名针对的 digest %s 与镜像 digest %s 不一致", p.Critical.Image.DockerManifestDigest, digest)
+	}
+	return nil
+}
+
+// verifyCertificate 校验 keyless 签名的证书，返回证书中的公钥
+//   - 通过 Rekor 凭证获取签名写入透明日志的时间，并确认日志条目记录的正是该签名
+//   - 以该时间校验 Fulcio 证书链 (Fulcio 证书有效期只有 10 分钟)
+//   - 校验证书中的签名身份与 OIDC 签发者
+func (v *Verifier) verifyCertificate(annotations map[string]string, payload, sig []byte) (crypto.PublicKey, error) {
+	certPEM := annotations[AnnotationCertificate]
+	block, _ := pem.Decode([]byte(certPEM))
+	if block == nil {
+		return nil, fmt.Errorf("缺少 %s 注解中的签名证书", AnnotationCertificate)
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("解析签名证书失败: %w", err)
+	}
+
+	signedAt, err := v.verifyBundle(annotations[AnnotationBundle], payload, sig)
+	if err != nil {
+		return nil, err
+	}
+
+	intermediates := v.intermediates.Clone()
+	intermediates.AppendCertsFromPEM([]byte(annotations[AnnotationChain]))
+	if _, err := cert.Verify(x509.VerifyOptions{
+		Roots:         v.roots,
+		Intermediates: intermediates,
+		CurrentTime:   signedAt,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+	}); err != nil {
+		return nil, fmt.Errorf("签名证书校验失败: %w", err)
+	}
+
+	identities := append([]string{}, cert.EmailAddresses...)
+	for _, u := range cert.URIs {
+		identities = append(identities, u.String())
+	}
+	if !v.matchIdentity(identities) {
+		return nil, fmt.Errorf("签名身份 %v 不符合规则", identities)
+	}
+	if issuer := certIssuer(cert); issuer != v.policy.Issuer {
+		return nil, fmt.Errorf("OIDC 签发者 %q 与规则 %q 不一致", issuer, v.policy.Issuer)
+	}
+	return cert.PublicKey, nil
+}
+
+func (v *Verifier) matchIdentity(identities []string) bool {
+	for _, id := range identities {
+		if v.identity != nil && v.identity.MatchString(id) {
+			return true
+		}
+		if v.identity == nil && id == v.policy.Identity {
+			return true
+		}
+	}
+	return false
+}
+
+// rekorBundle 是 cosign 保存在签名层注解中的 Rekor 凭证
+type rekorBundle struct {
+	SignedEntryTimestamp []byte       `json:"SignedEntryTimestamp"`
+	Payload              rekorPayload `json:"Payload"`
+}
+
+// rekorPayload 的字段按名称排序，json.Marshal 的结果即为 Rekor 签名时使用的规范化 JSON
+type rekorPayload struct {
+	Body           string `json:"body"`
+	IntegratedTime int64  `json:"integratedTime"`
+	LogID          string `json:"logID"`
+	LogIndex       int64  `json:"logIndex"`
+}
+
+// hashedRekord 是 Rekor 中 hashedrekord 类型条目的内容
+type hashedRekord struct {
+	Kind string `json:"kind"`
+	Spec struct {
+		Data struct {
+			Hash struct {
+				Algorithm string `json:"algorithm"`
+				Value     string `json:"value"`
+			} `json:"hash"`
+		} `json:"data"`
+		Signature struct {
+			Content string `json:"content"`
+		} `json:"signature"`
+	} `json:"spec"`
+}
+
+// verifyBundle 校验 Rekor 凭证的签名，并确认日志条目记录了该签名与载荷摘要，返回写入日志的时间
+func (v *Verifier) verifyBundle(raw string, payload, sig []byte) (time.Time, error) {
+	if raw == "" {
+		return time.Time{}, fmt.Errorf("缺少 %s 注解中的 Rekor 凭证", AnnotationBundle)
+	}
+	var bundle rekorBundle
+	if err := json.Unmarshal([]byte(raw), &bundle); err != nil {
+		return time.Time{}, fmt.Errorf("解析 Rekor 凭证失败: %w", err)
+	}
+
+	canonical, err := json.Marshal(bundle.Payload)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if err := verifySignature(v.rekorKey, canonical, bundle.SignedEntryTimestamp); err != nil {
+		return time.Time{}, fmt.Errorf("Rekor 凭证校验失败: %w", err)
+	}
+
+	body, err := base64.StdEncoding.DecodeString(bundle.Payload.Body)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("解析 Rekor 日志条目失败: %w", err)
+	}
+	var entry hashedRekord
+	if err := json.Unmarshal(body, &entry); err != nil {
+		return time.Time{}, fmt.Errorf("解析 Rekor 日志条目失败: %w", err)
+	}
+	if entry.Kind != "hashedrekord" {
+		return time.Time{}, fmt.Errorf("不支持的 Rekor 日志条目类型 %q", entry.Kind)
+	}
+	digest := sha256.Sum256(payload)
+	if entry.Spec.Data.Hash.Algorithm != "sha256" || entry.Spec.Data.Hash.Value != hex.EncodeToString(digest[:]) {
+		return time.Time{}, fmt.Errorf("Rekor 日志条目中的载荷摘要与签名载荷不一致")
+	}
+	logged, err := base64.StdEncoding.DecodeString(entry.Spec.Signature.Content)
+	if err != nil || !bytes.Equal(logged, sig) {
+		return time.Time{}, fmt.Errorf("Rekor 日志条目中的签名与镜像签名不一致")
+	}
+	return time.Unix(bundle.Payload.IntegratedTime, 0), nil
+}
+
+// certIssuer 读取 Fulcio 证书中的 OIDC 签发者扩展
+func certIssuer(cert *x509.Certificate) string {
+	for _, ext := range cert.Extensions {
+		switch {
+		case ext.Id.Equal(oidIssuerV2):
+			var issuer string
+			if _, err := asn1.Unmarshal(ext.Value, &issuer); err == nil {
+				return issuer
+			}
+		case ext.Id.Equal(oidIssuerV1):
+			return string(ext.Value)
+		}
+	}
+	return ""
+}
+
+// loadCertPools 读取 PEM 证书文件，自签名证书作为根证书，其余作为中间证书
+func loadCertPools(path string) (*x509.CertPool, *x509.CertPool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("读取根证书 %s 失败: %w", path, err)
+	}
+	roots, intermediates := x509.NewCertPool(), x509.NewCertPool()
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, nil, fmt.Errorf("解析根证书 %s 失败: %w", path, err)
+		}
+		if bytes.Equal(cert.RawIssuer, cert.RawSubject) {
+			roots.AddCert(cert)
+		} else {
+			intermediates.AddCert(cert)
+		}
+	}
+	if roots.Equal(x509.NewCertPool()) {
+		return nil, nil, fmt.Errorf("根证书 %s 中没有自签名的根证书", path)
+	}
+	return roots, intermediates, nil
+}
@@ -0,0 +1,296 @@
+package signature
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/static"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+)
+
+const (
+	testIdentity = "dev@example.com"
+	testIssuer   = "https://issuer.example.com"
+)
+
+// sigLayer 是写入 .sig Tag 的一个签名层
+type sigLayer struct {
+	payload     []byte
+	annotations map[string]string
+}
+
+// writeSignatures 将签名层写入 repo 中 digest 对应的 .sig Tag
+func writeSignatures(t *testing.T, repo name.Repository, digest string, layers []sigLayer) {
+	t.Helper()
+	img := mutate.ConfigMediaType(mutate.MediaType(empty.Image, types.OCIManifestSchema1), types.OCIConfigJSON)
+	for _, l := range layers {
+		var err error
+		img, err = mutate.Append(img, mutate.Addendum{
+			Layer:       static.NewLayer(l.payload, SimpleSigningMediaType),
+			Annotations: l.annotations,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := remote.Write(repo.Tag(SignatureTag(digest)), img); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// testPayload 返回指向 digest 的 simple signing 载荷
+func testPayload(t *testing.T, digest string) []byte {
+	t.Helper()
+	var p Payload
+	p.Critical.Identity.DockerReference = "example.com/library/app"
+	p.Critical.Image.DockerManifestDigest = digest
+	p.Critical.Type = payloadType
+	data, err := json.Marshal(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func newKey(t *testing.T) *ecdsa.PrivateKey {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+// signLayer 使用 key 对载荷签名，返回带签名注解的签名层
+func signLayer(t *testing.T, key crypto.Signer, payload []byte) sigLayer {
+	t.Helper()
+	sig, err := (&Signer{key: key}).sign(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sigLayer{
+		payload:     payload,
+		annotations: map[string]string{AnnotationSignature: base64.StdEncoding.EncodeToString(sig)},
+	}
+}
+
+// keylessEnv 模拟 Fulcio 与 Rekor：CA 签发带签名身份与 OIDC 签发者的短期证书，Rekor 私钥为日志条目签名
+type keylessEnv struct {
+	ca       *x509.Certificate
+	caKey    *ecdsa.PrivateKey
+	rekorKey *ecdsa.PrivateKey
+	roots    string // CA 证书路径
+	rekorPub string // Rekor 公钥路径
+}
+
+func newKeylessEnv(t *testing.T) *keylessEnv {
+	t.Helper()
+	env := &keylessEnv{caKey: newKey(t), rekorKey: newKey(t)}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test fulcio"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &env.caKey.PublicKey, env.caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if env.ca, err = x509.ParseCertificate(der); err != nil {
+		t.Fatal(err)
+	}
+	env.roots = writePEM(t, "CERTIFICATE", der)
+	env.rekorPub = writePublicKey(t, &env.rekorKey.PublicKey)
+	return env
+}
+
+// policy 返回使用该环境信任根的 keyless 规则
+func (env *keylessEnv) policy(identity, issuer string) Policy {
+	return Policy{Identity: identity, Issuer: issuer, Roots: env.roots, RekorKey: env.rekorPub}
+}
+
+// sign 模拟 cosign keyless 签名：签发证书、签名并生成 Rekor 凭证，tamper 可在 Rekor 签名后修改凭证
+func (env *keylessEnv) sign(t *testing.T, payload []byte, tamper func(*rekorBundle)) sigLayer {
+	t.Helper()
+	key := newKey(t)
+	issuer, err := asn1.Marshal(testIssuer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:    big.NewInt(2),
+		NotBefore:       time.Now().Add(-time.Minute),
+		NotAfter:        time.Now().Add(10 * time.Minute),
+		EmailAddresses:  []string{testIdentity},
+		KeyUsage:        x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:     []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+		ExtraExtensions: []pkix.Extension{{Id: oidIssuerV2, Value: issuer}},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, env.ca, &key.PublicKey, env.caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	layer := signLayer(t, key, payload)
+	sig, _ := base64.StdEncoding.DecodeString(layer.annotations[AnnotationSignature])
+
+	var entry hashedRekord
+	entry.Kind = "hashedrekord"
+	digest := sha256.Sum256(payload)
+	entry.Spec.Data.Hash.Algorithm = "sha256"
+	entry.Spec.Data.Hash.Value = hex.EncodeToString(digest[:])
+	entry.Spec.Signature.Content = base64.StdEncoding.EncodeToString(sig)
+	body, err := json.Marshal(entry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bundle := rekorBundle{Payload: rekorPayload{
+		Body:           base64.StdEncoding.EncodeToString(body),
+		IntegratedTime: time.Now().Unix(),
+		LogID:          "test",
+		LogIndex:       1,
+	}}
+	canonical, err := json.Marshal(bundle.Payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bundle.SignedEntryTimestamp, err = (&Signer{key: env.rekorKey}).sign(canonical); err != nil {
+		t.Fatal(err)
+	}
+	if tamper != nil {
+		tamper(&bundle)
+	}
+	rawBundle, err := json.Marshal(bundle)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	layer.annotations[AnnotationCertificate] = string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+	layer.annotations[AnnotationBundle] = string(rawBundle)
+	return layer
+}
+
+func TestVerify(t *testing.T) {
+	key, otherKey := newKey(t), newKey(t)
+	keyPolicy := Policy{Key: writePublicKey(t, &key.PublicKey)}
+	env := newKeylessEnv(t)
+
+	tests := []struct {
+		name    string
+		policy  Policy
+		layers  func(t *testing.T, digest string) []sigLayer // 为空时不写入 .sig Tag
+		wantErr string                                       // 为空表示校验通过
+	}{
+		{
+			name:   "公钥签名有效",
+			policy: keyPolicy,
+			layers: func(t *testing.T, digest string) []sigLayer {
+				return []sigLayer{signLayer(t, key, testPayload(t, digest))}
+			},
+		},
+		{
+			name:   "任意一个签名通过即可",
+			policy: keyPolicy,
+			layers: func(t *testing.T, digest string) []sigLayer {
+				return []sigLayer{signLayer(t, otherKey, testPayload(t, digest)), signLayer(t, key, testPayload(t, digest))}
+			},
+		},
+		{
+			name:   "公钥不匹配",
+			policy: keyPolicy,
+			layers: func(t *testing.T, digest string) []sigLayer {
+				return []sigLayer{signLayer(t, otherKey, testPayload(t, digest))}
+			},
+			wantErr: "ECDSA 签名无效",
+		},
+		{
+			name:   "载荷 digest 不一致",
+			policy: keyPolicy,
+			layers: func(t *testing.T, digest string) []sigLayer {
+				other := "sha256:" + strings.Repeat("0", 64)
+				return []sigLayer{signLayer(t, key, testPayload(t, other))}
+			},
+			wantErr: "与镜像 digest",
+		},
+		{
+			name:    "缺少签名",
+			policy:  keyPolicy,
+			wantErr: ErrNoSignature.Error(),
+		},
+		{
+			name:   "keyless 签名有效",
+			policy: env.policy(testIdentity, testIssuer),
+			layers: func(t *testing.T, digest string) []sigLayer {
+				return []sigLayer{env.sign(t, testPayload(t, digest), nil)}
+			},
+		},
+		{
+			name:   "Rekor 凭证被篡改",
+			policy: env.policy(testIdentity, testIssuer),
+			layers: func(t *testing.T, digest string) []sigLayer {
+				return []sigLayer{env.sign(t, testPayload(t, digest), func(b *rekorBundle) { b.Payload.IntegratedTime-- })}
+			},
+			wantErr: "Rekor 凭证校验失败",
+		},
+		{
+			name:   "签名身份不符",
+			policy: env.policy("ops@example.com", testIssuer),
+			layers: func(t *testing.T, digest string) []sigLayer {
+				return []sigLayer{env.sign(t, testPayload(t, digest), nil)}
+			},
+			wantErr: "签名身份 [dev@example.com] 不符合规则",
+		},
+		{
+			name:   "OIDC 签发者不符",
+			policy: env.policy(testIdentity, "https://accounts.example.com"),
+			layers: func(t *testing.T, digest string) []sigLayer {
+				return []sigLayer{env.sign(t, testPayload(t, digest), nil)}
+			},
+			wantErr: "OIDC 签发者",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, digest := newTestImage(t)
+			if tt.layers != nil {
+				writeSignatures(t, repo, digest, tt.layers(t, digest))
+			}
+			v, err := NewVerifier(tt.policy)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = v.Verify(repo, digest)
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Errorf("Verify: %v", err)
+			case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Errorf("Verify err = %v, want 包含 %q", err, tt.wantErr)
+			}
+			if tt.layers == nil && !errors.Is(err, ErrNoSignature) {
+				t.Errorf("缺少签名时应返回 ErrNoSignature: %v", err)
+			}
+		})
+	}
+}
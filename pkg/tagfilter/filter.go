@@ -0,0 +1,133 @@
+package tagfilter
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"time"
+)
+
+// Selector 描述对镜像 Tag 列表的筛选规则，各规则按字段顺序依次生效
+type Selector struct {
+	Include    string `yaml:"include"`    // 正则，仅保留匹配的 tag
+	Exclude    string `yaml:"exclude"`    // 正则，排除匹配的 tag
+	Semver     string `yaml:"semver"`     // 版本约束，如 ">=1.18 <2.0"
+	Prerelease bool   `yaml:"prerelease"` // 预发布版本 (如 1.19.0-beta.1、1.25-alpine) 是否参与 semver/latest 筛选
+	Latest     int    `yaml:"latest"`     // 按语义化版本保留最新的 N 个
+	Newest     int    `yaml:"newest"`     // 按镜像创建时间保留最新的 N 个
+}
+
+// IsZero 判断是否未配置任何规则
+func (s Selector) IsZero() bool {
+	return s == Selector{}
+}
+
+// CreatedLookup 批量查询 tag 对应镜像的创建时间，仅在配置了 newest 时调用
+type CreatedLookup func(tags []string) (map[string]time.Time, error)
+
+// Filter 是校验并编译后的 Selector
+type Filter struct {
+	selector   Selector
+	include    *regexp.Regexp
+	exclude    *regexp.Regexp
+	constraint constraint
+}
+
+// New 校验 Selector 并编译其中的正则与版本约束
+func New(sel Selector) (*Filter, error) {
+	f := &Filter{selector: sel}
+
+	var err error
+	if sel.Include != "" {
+		if f.include, err = regexp.Compile(sel.Include); err != nil {
+			return nil, fmt.Errorf("include 正则无效: %w", err)
+		}
+	}
+	if sel.Exclude != "" {
+		if f.exclude, err = regexp.Compile(sel.Exclude); err != nil {
+			return nil, fmt.Errorf("exclude 正则无效: %w", err)
+		}
+	}
+	if sel.Semver != "" {
+		if f.constraint, err = parseConstraint(sel.Semver); err != nil {
+			return nil, err
+		}
+	}
+	if sel.Latest < 0 {
+		return nil, fmt.Errorf("latest 不能为负数: %d", sel.Latest)
+	}
+	if sel.Newest < 0 {
+		return nil, fmt.Errorf("newest 不能为负数: %d", sel.Newest)
+	}
+	return f, nil
+}
+
+// Apply 依次执行 include/exclude、semver 约束、latest N 与 newest N 筛选
+func (f *Filter) Apply(tags []string, created CreatedLookup) ([]string, error) {
+	var result []string
+	for _, tag := range tags {
+		if f.include != nil && !f.include.MatchString(tag) {
+			continue
+		}
+		if f.exclude != nil && f.exclude.MatchString(tag) {
+			continue
+		}
+		if f.constraint != nil {
+			v, ok := f.version(tag)
+			if !ok || !f.constraint.match(v) {
+				continue
+			}
+		}
+		result = append(result, tag)
+	}
+
+	if f.selector.Latest > 0 {
+		type versioned struct {
+			tag     string
+			version version
+		}
+		var candidates []versioned
+		for _, tag := range result {
+			if v, ok := f.version(tag); ok {
+				candidates = append(candidates, versioned{tag: tag, version: v})
+			}
+		}
+		sort.SliceStable(candidates, func(i, j int) bool {
+			return candidates[i].version.compare(candidates[j].version) > 0
+		})
+		if len(candidates) > f.selector.Latest {
+			candidates = candidates[:f.selector.Latest]
+		}
+		result = result[:0]
+		for _, c := range candidates {
+			result = append(result, c.tag)
+		}
+	}
+
+	if f.selector.Newest > 0 && len(result) > 0 {
+		if created == nil {
+			return nil, fmt.Errorf("newest 筛选需要查询镜像创建时间")
+		}
+		times, err := created(result)
+		if err != nil {
+			return nil, fmt.Errorf("查询镜像创建时间失败: %w", err)
+		}
+		sort.SliceStable(result, func(i, j int) bool {
+			return times[result[i]].After(times[result[j]])
+		})
+		if len(result) > f.selector.Newest {
+			result = result[:f.selector.Newest]
+		}
+	}
+
+	return result, nil
+}
+
+// version 解析 tag 的版本号，未开启 prerelease 时忽略预发布版本
+func (f *Filter) version(tag string) (version, bool) {
+	v, ok := parseVersion(tag)
+	if !ok || (v.prerelease != "" && !f.selector.Prerelease) {
+		return version{}, false
+	}
+	return v, true
+}
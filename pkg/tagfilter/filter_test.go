@@ -0,0 +1,76 @@
+package tagfilter
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestFilterApply(t *testing.T) {
+	tags := []string{"latest", "1.17.0", "1.18.0", "v1.18.1", "1.19.0-rc.1", "1.19.0", "2.0.0", "1.25-alpine"}
+	tests := []struct {
+		name     string
+		selector Selector
+		want     []string
+	}{
+		{name: "include", selector: Selector{Include: `^1\.18`}, want: []string{"1.18.0"}},
+		{name: "exclude", selector: Selector{Exclude: `alpine|rc|latest`}, want: []string{"1.17.0", "1.18.0", "v1.18.1", "1.19.0", "2.0.0"}},
+		{name: "semver 忽略非版本号与预发布", selector: Selector{Semver: ">=1.18 <2.0"}, want: []string{"1.18.0", "v1.18.1", "1.19.0"}},
+		{name: "semver 含预发布", selector: Selector{Semver: ">=1.19.0-0", Prerelease: true}, want: []string{"1.19.0-rc.1", "1.19.0", "2.0.0", "1.25-alpine"}},
+		{name: "latest", selector: Selector{Latest: 2}, want: []string{"2.0.0", "1.19.0"}},
+		{name: "semver 后取 latest", selector: Selector{Semver: "<2", Latest: 2}, want: []string{"1.19.0", "v1.18.1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := New(tt.selector)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := f.Apply(tags, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Apply() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFilterNewest(t *testing.T) {
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	lookup := func(tags []string) (map[string]time.Time, error) {
+		return map[string]time.Time{
+			"a": base,
+			"b": base.Add(2 * time.Hour),
+			"c": base.Add(time.Hour),
+		}, nil
+	}
+	f, err := New(Selector{Newest: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := f.Apply([]string{"a", "b", "c"}, lookup)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"b", "c"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Apply() = %v, want %v", got, want)
+	}
+
+	failing := func(tags []string) (map[string]time.Time, error) {
+		return nil, errors.New("以下标签无法获取创建时间: a")
+	}
+	if _, err := f.Apply([]string{"a", "b", "c"}, failing); err == nil {
+		t.Error("查询创建时间失败时应返回错误")
+	}
+}
+
+func TestNewInvalid(t *testing.T) {
+	for _, sel := range []Selector{{Include: "("}, {Exclude: "["}, {Semver: ">=x"}, {Latest: -1}, {Newest: -1}} {
+		if _, err := New(sel); err == nil {
+			t.Errorf("New(%+v) 应返回错误", sel)
+		}
+	}
+}
@@ -0,0 +1,175 @@
+package tagfilter
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// version 是简化的语义化版本，支持 v 前缀与缺省的次/修订版本号 (如 v1.18 视为 1.18.0)
+type version struct {
+	major, minor, patch int
+	prerelease          string
+}
+
+// parseVersion 解析 tag 形式的版本号，如 v1.19.0、1.25-alpine、1.18.0-beta.1+build
+func parseVersion(s string) (version, bool) {
+	s = strings.TrimPrefix(s, "v")
+	if i := strings.Index(s, "+"); i >= 0 {
+		s = s[:i]
+	}
+
+	var v version
+	if i := strings.Index(s, "-"); i >= 0 {
+		v.prerelease = s[i+1:]
+		s = s[:i]
+		if v.prerelease == "" {
+			return version{}, false
+		}
+	}
+
+	parts := strings.Split(s, ".")
+	if len(parts) == 0 || len(parts) > 3 {
+		return version{}, false
+	}
+	nums := make([]int, 3)
+	for i, p := range parts {
+		if p == "" || strings.TrimLeft(p, "0123456789") != "" {
+			return version{}, false
+		}
+		n, err := strconv.Atoi(p)
+		if err != nil {
+			return version{}, false
+		}
+		nums[i] = n
+	}
+	v.major, v.minor, v.patch = nums[0], nums[1], nums[2]
+	return v, true
+}
+
+// compare 按语义化版本的优先级比较，返回 -1、0 或 1
+func (v version) compare(o version) int {
+	for _, d := range []int{v.major - o.major, v.minor - o.minor, v.patch - o.patch} {
+		if d < 0 {
+			return -1
+		}
+		if d > 0 {
+			return 1
+		}
+	}
+	return comparePrerelease(v.prerelease, o.prerelease)
+}
+
+// comparePrerelease 实现 semver 2.0 的预发布版本比较规则：正式版本高于任何预发布版本
+func comparePrerelease(a, b string) int {
+	switch {
+	case a == b:
+		return 0
+	case a == "":
+		return 1
+	case b == "":
+		return -1
+	}
+
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		an, aErr := strconv.Atoi(as[i])
+		bn, bErr := strconv.Atoi(bs[i])
+		switch {
+		case aErr == nil && bErr == nil:
+			if an != bn {
+				return sign(an - bn)
+			}
+		case aErr == nil:
+			return -1 // 数字标识符低于字母标识符
+		case bErr == nil:
+			return 1
+		default:
+			if c := strings.Compare(as[i], bs[i]); c != 0 {
+				return c
+			}
+		}
+	}
+	return sign(len(as) - len(bs))
+}
+
+func sign(n int) int {
+	switch {
+	case n < 0:
+		return -1
+	case n > 0:
+		return 1
+	}
+	return 0
+}
+
+// comparator 是单个版本比较条件，如 >=1.18
+type comparator struct {
+	op      string
+	version version
+}
+
+func (c comparator) match(v version) bool {
+	r := v.compare(c.version)
+	switch c.op {
+	case ">":
+		return r > 0
+	case ">=":
+		return r >= 0
+	case "<":
+		return r < 0
+	case "<=":
+		return r <= 0
+	case "!=":
+		return r != 0
+	default:
+		return r == 0
+	}
+}
+
+// constraint 是版本约束：空格分隔的条件同时满足 (AND)，"||" 分隔的组任一满足 (OR)
+type constraint [][]comparator
+
+// parseConstraint 解析形如 ">=1.18 <2.0" 或 ">=1.18 <1.20 || >=2.1" 的版本约束，支持 >、>=、<、<=、=、!=
+func parseConstraint(s string) (constraint, error) {
+	var c constraint
+	for _, group := range strings.Split(s, "||") {
+		fields := strings.Fields(group)
+		if len(fields) == 0 {
+			return nil, fmt.Errorf("版本约束 %q 存在空的条件组", s)
+		}
+		var comps []comparator
+		for _, f := range fields {
+			op := ""
+			for _, candidate := range []string{">=", "<=", "!=", ">", "<", "="} {
+				if strings.HasPrefix(f, candidate) {
+					op = candidate
+					break
+				}
+			}
+			v, ok := parseVersion(strings.TrimPrefix(f, op))
+			if !ok {
+				return nil, fmt.Errorf("版本约束 %q 中 %q 不是有效的版本号", s, f)
+			}
+			comps = append(comps, comparator{op: op, version: v})
+		}
+		c = append(c, comps)
+	}
+	return c, nil
+}
+
+func (c constraint) match(v version) bool {
+	for _, group := range c {
+		ok := true
+		for _, comp := range group {
+			if !comp.match(v) {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			return true
+		}
+	}
+	return false
+}
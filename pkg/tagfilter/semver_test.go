@@ -0,0 +1,120 @@
+package tagfilter
+
+import "testing"
+
+func TestParseVersion(t *testing.T) {
+	tests := []struct {
+		tag  string
+		want version
+		ok   bool
+	}{
+		{tag: "1.19.0", want: version{1, 19, 0, ""}, ok: true},
+		{tag: "v1.19.0", want: version{1, 19, 0, ""}, ok: true},
+		{tag: "v1.18", want: version{1, 18, 0, ""}, ok: true},
+		{tag: "2", want: version{2, 0, 0, ""}, ok: true},
+		{tag: "1.25-alpine", want: version{1, 25, 0, "alpine"}, ok: true},
+		{tag: "1.18.0-beta.1+build.5", want: version{1, 18, 0, "beta.1"}, ok: true},
+		{tag: "1.18.0+build", want: version{1, 18, 0, ""}, ok: true},
+		{tag: "latest"},
+		{tag: ""},
+		{tag: "v"},
+		{tag: "1.2.3.4"},
+		{tag: "1..2"},
+		{tag: "1.2-"},
+		{tag: "1.x"},
+		{tag: "-1.2"},
+		{tag: "V1.2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.tag, func(t *testing.T) {
+			got, ok := parseVersion(tt.tag)
+			if ok != tt.ok {
+				t.Fatalf("parseVersion(%q) ok = %v, want %v", tt.tag, ok, tt.ok)
+			}
+			if ok && got != tt.want {
+				t.Errorf("parseVersion(%q) = %+v, want %+v", tt.tag, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVersionCompare(t *testing.T) {
+	// semver 2.0 规范中的优先级示例，按从低到高排列
+	ordered := []string{
+		"1.0.0-alpha",
+		"1.0.0-alpha.1",
+		"1.0.0-alpha.beta",
+		"1.0.0-beta",
+		"1.0.0-beta.2",
+		"1.0.0-beta.11",
+		"1.0.0-rc.1",
+		"1.0.0",
+		"v1.0.1",
+		"1.2",
+		"1.10.0",
+		"2.0.0-rc.1",
+		"2.0.0",
+	}
+	for i := range ordered {
+		for j := range ordered {
+			a, _ := parseVersion(ordered[i])
+			b, _ := parseVersion(ordered[j])
+			want := sign(i - j)
+			if got := a.compare(b); got != want {
+				t.Errorf("compare(%s, %s) = %d, want %d", ordered[i], ordered[j], got, want)
+			}
+		}
+	}
+
+	a, _ := parseVersion("v1.18")
+	b, _ := parseVersion("1.18.0+build")
+	if a.compare(b) != 0 {
+		t.Errorf("v1.18 与 1.18.0+build 应视为相同版本")
+	}
+}
+
+func TestConstraint(t *testing.T) {
+	tests := []struct {
+		constraint string
+		matches    []string
+		rejects    []string
+	}{
+		{constraint: ">=1.18 <2.0", matches: []string{"1.18", "v1.18.0", "1.19.5"}, rejects: []string{"1.17.9", "2.0.0", "2.1"}},
+		{constraint: ">1.18", matches: []string{"1.18.1"}, rejects: []string{"1.18.0"}},
+		{constraint: "<=1.18", matches: []string{"1.18.0", "1.0"}, rejects: []string{"1.18.1"}},
+		{constraint: "=1.18", matches: []string{"v1.18.0"}, rejects: []string{"1.18.1"}},
+		{constraint: "1.18", matches: []string{"1.18.0"}, rejects: []string{"1.19"}},
+		{constraint: "!=1.18", matches: []string{"1.19"}, rejects: []string{"1.18.0"}},
+		{constraint: ">=1.18 <1.20 || >=2.1", matches: []string{"1.19", "2.1.0", "3.0"}, rejects: []string{"1.20", "2.0.5"}},
+		{constraint: "<1.0.0", matches: []string{"1.0.0-rc.1"}, rejects: []string{"1.0.0"}},
+		{constraint: ">=v1.18", matches: []string{"1.18"}, rejects: []string{"1.17"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.constraint, func(t *testing.T) {
+			c, err := parseConstraint(tt.constraint)
+			if err != nil {
+				t.Fatalf("parseConstraint(%q): %v", tt.constraint, err)
+			}
+			for _, tag := range tt.matches {
+				v, _ := parseVersion(tag)
+				if !c.match(v) {
+					t.Errorf("%q 应匹配 %s", tt.constraint, tag)
+				}
+			}
+			for _, tag := range tt.rejects {
+				v, _ := parseVersion(tag)
+				if c.match(v) {
+					t.Errorf("%q 不应匹配 %s", tt.constraint, tag)
+				}
+			}
+		})
+	}
+}
+
+func TestParseConstraintInvalid(t *testing.T) {
+	for _, s := range []string{"", ">=1.18 ||", ">=latest", ">=1.18 <", "~1.2", "^1.2", ">=1.2.3.4"} {
+		if _, err := parseConstraint(s); err == nil {
+			t.Errorf("parseConstraint(%q) 应返回错误", s)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package tlsutil
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+)
+
+// Options 定义连接仓库时的 TLS 设置
+type Options struct {
+	Insecure   bool   // 跳过证书校验 (同时允许 HTTP)
+	CAFile     string // 额外信任的 CA 证书 (PEM)，与系统根证书一起使用
+	CertFile   string // 双向 TLS 的客户端证书 (PEM)
+	KeyFile    string // 双向 TLS 的客户端私钥 (PEM)
+	ServerName string // 覆盖校验证书时使用的服务器名称 (SNI)
+}
+
+// Config 根据 Options 生成 tls.Config
+func (o Options) Config() (*tls.Config, error) {
+	cfg := &tls.Config{
+		InsecureSkipVerify: o.Insecure,
+		ServerName:         o.ServerName,
+	}
+
+	if o.CAFile != "" {
+		pem, err := os.ReadFile(o.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("读取 CA 证书 %s 失败: %w", o.CAFile, err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("CA 证书 %s 中没有有效的 PEM 证书", o.CAFile)
+		}
+		cfg.RootCAs = pool
+	}
+
+	if (o.CertFile == "") != (o.KeyFile == "") {
+		return nil, fmt.Errorf("客户端证书 cert_file 与私钥 key_file 必须同时配置")
+	}
+	if o.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(o.CertFile, o.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("加载客户端证书 %s 失败: %w", o.CertFile, err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+
+	return cfg, nil
+}
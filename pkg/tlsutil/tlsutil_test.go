@@ -0,0 +1,236 @@
+package tlsutil
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"log"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testCA 是测试用的私有 CA，签发服务端与客户端证书
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	dir  string
+}
+
+func newTestCA(t *testing.T) *testCA {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "ikl test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testCA{cert: cert, key: key, dir: t.TempDir()}
+}
+
+// file 将 PEM 数据写入临时目录并返回路径
+func (ca *testCA) file(t *testing.T, name string, data []byte) string {
+	t.Helper()
+	path := filepath.Join(ca.dir, name)
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// caFile 返回 CA 证书的 PEM 文件路径
+func (ca *testCA) caFile(t *testing.T) string {
+	return ca.file(t, "ca.pem", pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca.cert.Raw}))
+}
+
+// issue 签发证书，返回 tls.Certificate 以及证书与私钥的 PEM 文件路径
+func (ca *testCA) issue(t *testing.T, name string, usage x509.ExtKeyUsage, dnsNames []string, ips []net.IP) (tls.Certificate, string, string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+		DNSNames:     dnsNames,
+		IPAddresses:  ips,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	pair, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pair, ca.file(t, name+".pem", certPEM), ca.file(t, name+"-key.pem", keyPEM)
+}
+
+// newTLSServer 启动使用 CA 签发证书的 HTTPS 服务，requireClientCert 为 true 时要求客户端证书 (双向 TLS)
+// 响应内容为客户端证书的 CommonName
+func newTLSServer(t *testing.T, ca *testCA, requireClientCert bool) *httptest.Server {
+	t.Helper()
+	serverCert, _, _ := ca.issue(t, "registry.internal", x509.ExtKeyUsageServerAuth, []string{"registry.internal"}, []net.IP{net.ParseIP("127.0.0.1")})
+
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(r.TLS.PeerCertificates) > 0 {
+			io.WriteString(w, r.TLS.PeerCertificates[0].Subject.CommonName)
+		}
+	}))
+	srv.Config.ErrorLog = log.New(io.Discard, "", 0)
+	srv.TLS = &tls.Config{Certificates: []tls.Certificate{serverCert}}
+	if requireClientCert {
+		pool := x509.NewCertPool()
+		pool.AddCert(ca.cert)
+		srv.TLS.ClientAuth = tls.RequireAndVerifyClientCert
+		srv.TLS.ClientCAs = pool
+	}
+	srv.StartTLS()
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// get 使用 opts 生成的 tls.Config 请求 url，返回响应内容
+func get(opts Options, url string) (string, error) {
+	cfg, err := opts.Config()
+	if err != nil {
+		return "", err
+	}
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: cfg}}
+	defer client.CloseIdleConnections()
+
+	resp, err := client.Get(url)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	return string(body), err
+}
+
+func TestConfigServerVerification(t *testing.T) {
+	ca := newTestCA(t)
+	srv := newTLSServer(t, ca, false)
+	caFile := ca.caFile(t)
+
+	tests := []struct {
+		name    string
+		opts    Options
+		wantErr string
+	}{
+		{"默认不信任私有 CA", Options{}, "certificate"},
+		{"ca_file", Options{CAFile: caFile}, ""},
+		{"insecure 跳过校验", Options{Insecure: true}, ""},
+		{"insecure 优先于 server_name", Options{Insecure: true, ServerName: "other.internal"}, ""},
+		{"server_name 与证书一致", Options{CAFile: caFile, ServerName: "registry.internal"}, ""},
+		{"server_name 与证书不一致", Options{CAFile: caFile, ServerName: "other.internal"}, "other.internal"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := get(tt.opts, srv.URL)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("err = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("err = %v, want 包含 %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestConfigClientCertificate(t *testing.T) {
+	ca := newTestCA(t)
+	srv := newTLSServer(t, ca, true)
+	caFile := ca.caFile(t)
+	_, certFile, keyFile := ca.issue(t, "ikl-client", x509.ExtKeyUsageClientAuth, nil, nil)
+
+	// 未配置客户端证书时服务端拒绝握手
+	if _, err := get(Options{CAFile: caFile}, srv.URL); err == nil {
+		t.Error("未配置客户端证书时请求成功")
+	}
+
+	body, err := get(Options{CAFile: caFile, CertFile: certFile, KeyFile: keyFile}, srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if body != "ikl-client" {
+		t.Errorf("服务端收到的客户端证书 = %q, want ikl-client", body)
+	}
+
+	// insecure 只跳过服务端证书校验，仍然发送客户端证书
+	body, err = get(Options{Insecure: true, CertFile: certFile, KeyFile: keyFile}, srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if body != "ikl-client" {
+		t.Errorf("服务端收到的客户端证书 = %q, want ikl-client", body)
+	}
+}
+
+func TestConfigErrors(t *testing.T) {
+	ca := newTestCA(t)
+	_, certFile, keyFile := ca.issue(t, "ikl-client", x509.ExtKeyUsageClientAuth, nil, nil)
+	_, otherCert, _ := ca.issue(t, "other", x509.ExtKeyUsageClientAuth, nil, nil)
+	invalid := ca.file(t, "invalid.pem", []byte("not a certificate"))
+
+	tests := []struct {
+		name string
+		opts Options
+		want string
+	}{
+		{"CA 文件不存在", Options{CAFile: filepath.Join(ca.dir, "missing.pem")}, "读取 CA 证书"},
+		{"CA 文件不是 PEM", Options{CAFile: invalid}, "没有有效的 PEM 证书"},
+		{"只配置 cert_file", Options{CertFile: certFile}, "必须同时配置"},
+		{"只配置 key_file", Options{KeyFile: keyFile}, "必须同时配置"},
+		{"证书与私钥不匹配", Options{CertFile: otherCert, KeyFile: keyFile}, "加载客户端证书"},
+		{"客户端证书不是 PEM", Options{CertFile: invalid, KeyFile: keyFile}, "加载客户端证书"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.opts.Config()
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("err = %v, want 包含 %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package ui
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// 支持的输出格式
+const (
+	FormatTable = "table"
+	FormatJSON  = "json"
+	FormatYAML  = "yaml"
+	FormatCSV   = "csv"
+)
+
+// ValidateFormat 校验 --output 参数
+func ValidateFormat(format string) error {
+	switch format {
+	case FormatTable, FormatJSON, FormatYAML, FormatCSV:
+		return nil
+	}
+	return fmt.Errorf("不支持的输出格式 %q (支持: table, json, yaml, csv)", format)
+}
+
+// IsStructured 判断是否为机器可读格式，此时提示信息应输出到 stderr，保持 stdout 可解析
+func IsStructured(format string) bool {
+	return format != FormatTable
+}
+
+// Render 按格式将结果写到 stdout
+// json/yaml 序列化 v，csv/table 使用 header 与 rows
+func Render(format string, v interface{}, header []string, rows [][]string) error {
+	switch format {
+	case FormatJSON:
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	case FormatYAML:
+		enc := yaml.NewEncoder(os.Stdout)
+		enc.SetIndent(2)
+		if err := enc.Encode(v); err != nil {
+			return err
+		}
+		return enc.Close()
+	case FormatCSV:
+		w := csv.NewWriter(os.Stdout)
+		if err := w.Write(header); err != nil {
+			return err
+		}
+		if err := w.WriteAll(rows); err != nil {
+			return err
+		}
+		return w.Error()
+	default:
+		RenderTable(header, rows)
+		return nil
+	}
+}
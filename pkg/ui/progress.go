@@ -0,0 +1,128 @@
+package ui
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/schollz/progressbar/v3"
+	"golang.org/x/term"
+)
+
+// MultiProgress 在终端底部同时渲染多个进度条，每个运行中的任务占用一行。
+// 并发任务的日志需通过 Printf 输出，以免与进度条互相覆盖。
+// 输出不是终端时 (如 CI 日志、管道) 不渲染进度条，仅逐行输出日志及任务完成时的进度。
+type MultiProgress struct {
+	mu    sync.Mutex
+	out   io.Writer
+	tty   bool // 输出是否为终端，仅终端下使用光标控制符刷新进度条
+	bars  []*progressbar.ProgressBar
+	lines int // 上一次渲染占用的行数
+	stop  chan struct{}
+	done  chan struct{}
+}
+
+// NewMultiProgress 创建并启动多进度条渲染器
+func NewMultiProgress() *MultiProgress {
+	return newMultiProgress(os.Stdout, term.IsTerminal(int(os.Stdout.Fd())))
+}
+
+func newMultiProgress(out io.Writer, tty bool) *MultiProgress {
+	m := &MultiProgress{
+		out:  out,
+		tty:  tty,
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+	if tty {
+		go m.loop()
+	} else {
+		close(m.done)
+	}
+	return m
+}
+
+// AddBar 新增一个字节进度条 (未知总量时显示为 spinner)
+func (m *MultiProgress) AddBar(description string) *progressbar.ProgressBar {
+	bar := progressbar.DefaultBytesSilent(-1, description)
+	_ = bar.RenderBlank()
+	m.mu.Lock()
+	m.bars = append(m.bars, bar)
+	m.mu.Unlock()
+	return bar
+}
+
+// RemoveBar 移除已完成的进度条，非终端输出时打印一行最终进度
+func (m *MultiProgress) RemoveBar(bar *progressbar.ProgressBar) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i, b := range m.bars {
+		if b == bar {
+			m.bars = append(m.bars[:i], m.bars[i+1:]...)
+			if !m.tty {
+				fmt.Fprintln(m.out, strings.TrimSpace(bar.String()))
+			}
+			break
+		}
+	}
+}
+
+// Printf 在进度条上方输出一行日志
+func (m *MultiProgress) Printf(format string, a ...interface{}) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.clear()
+	fmt.Fprintf(m.out, format, a...)
+	m.render()
+}
+
+// Stop 停止渲染并清除残留的进度条
+func (m *MultiProgress) Stop() {
+	close(m.stop)
+	<-m.done
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.clear()
+}
+
+func (m *MultiProgress) loop() {
+	defer close(m.done)
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-m.stop:
+			return
+		case <-ticker.C:
+			m.mu.Lock()
+			m.clear()
+			m.render()
+			m.mu.Unlock()
+		}
+	}
+}
+
+// clear 将光标移回进度条区域起始处并擦除其后内容，调用方需持有锁
+func (m *MultiProgress) clear() {
+	if m.lines > 0 {
+		fmt.Fprintf(m.out, "\033[%dA\033[J", m.lines)
+		m.lines = 0
+	}
+}
+
+// render 逐行输出当前所有进度条，调用方需持有锁
+func (m *MultiProgress) render() {
+	if !m.tty {
+		return
+	}
+	var sb strings.Builder
+	for _, bar := range m.bars {
+		sb.WriteString(strings.TrimSpace(bar.String()))
+		sb.WriteString("\n")
+	}
+	fmt.Fprint(m.out, sb.String())
+	m.lines = len(m.bars)
+}
@@ -0,0 +1,42 @@
+package ui
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestMultiProgressPlainOutput(t *testing.T) {
+	var buf bytes.Buffer
+	m := newMultiProgress(&buf, false)
+	bar := m.AddBar("nginx:1.25")
+	_ = bar.Add(1024)
+	m.Printf("✅ 推送完成: %s\n", "nginx:1.25")
+	m.RemoveBar(bar)
+	m.Stop()
+
+	out := buf.String()
+	if strings.Contains(out, "\033[") {
+		t.Fatalf("非终端输出不应包含光标控制符: %q", out)
+	}
+	if !strings.Contains(out, "✅ 推送完成: nginx:1.25\n") {
+		t.Errorf("缺少日志行: %q", out)
+	}
+	if !strings.Contains(out, "nginx:1.25") || strings.Count(out, "\n") != 2 {
+		t.Errorf("应输出日志及进度各一行: %q", out)
+	}
+}
+
+func TestMultiProgressTerminalOutput(t *testing.T) {
+	var buf bytes.Buffer
+	m := newMultiProgress(&buf, true)
+	bar := m.AddBar("nginx:1.25")
+	m.Printf("log\n")
+	m.Printf("log\n")
+	m.RemoveBar(bar)
+	m.Stop()
+
+	if !strings.Contains(buf.String(), "\033[1A\033[J") {
+		t.Errorf("终端输出应使用光标控制符刷新进度条: %q", buf.String())
+	}
+}